@@ -0,0 +1,215 @@
+package api
+
+import (
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"github.com/henges/later/later"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Config struct {
+	// ListenPort is the port the API listens on. If zero, the API is served
+	// on the same listener as the webhook bot.
+	ListenPort int `json:"listenPort"`
+	// Tokens maps bearer tokens to the owner they authenticate as.
+	Tokens map[string]string `json:"tokens"`
+}
+
+type Server struct {
+	l *later.Later
+	c *Config
+}
+
+func NewServer(l *later.Later, c *Config) *Server {
+	return &Server{l, c}
+}
+
+// Handler is satisfied by *http.ServeMux and *bot.WebhookBot.
+type Handler interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+func (s *Server) Register(h Handler) {
+	h.Handle("GET /openapi.yaml", http.HandlerFunc(serveOpenAPI))
+	h.Handle("POST /reminders", s.authed(s.createReminder))
+	h.Handle("GET /reminders", s.authed(s.listReminders))
+	h.Handle("PATCH /reminders/{id}", s.authed(s.updateReminder))
+	h.Handle("DELETE /reminders/{id}", s.authed(s.deleteReminder))
+}
+
+//go:embed openapi.yaml
+var openAPI []byte
+
+func serveOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	_, _ = w.Write(openAPI)
+}
+
+type authedHandler func(w http.ResponseWriter, r *http.Request, owner string)
+
+func (s *Server) authed(next authedHandler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok {
+			writeError(w, http.StatusUnauthorized, errors.New("missing bearer token"))
+			return
+		}
+		owner, ok := s.c.Tokens[token]
+		if !ok || token == "" {
+			writeError(w, http.StatusUnauthorized, errors.New("invalid bearer token"))
+			return
+		}
+		next(w, r, owner)
+	})
+}
+
+type createReminderRequest struct {
+	FireTime     time.Time `json:"fireTime"`
+	CallbackData string    `json:"callbackData"`
+	Tags         []string  `json:"tags"`
+}
+
+func (s *Server) createReminder(w http.ResponseWriter, r *http.Request, owner string) {
+
+	var req createReminderRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.FireTime.IsZero() {
+		writeError(w, http.StatusBadRequest, errors.New("fireTime is required"))
+		return
+	}
+	tags, err := later.NormalizeTags(req.Tags)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	rmd := later.Reminder{Owner: owner, FireTime: req.FireTime, CallbackData: req.CallbackData, Tags: tags}
+	id, err := s.l.InsertReminder(rmd)
+	if errors.Is(err, later.ErrLimitExceeded) {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, later.SavedReminder{ID: id, Reminder: rmd})
+}
+
+func (s *Server) listReminders(w http.ResponseWriter, r *http.Request, owner string) {
+
+	if q := r.URL.Query().Get("owner"); q != "" && q != owner {
+		writeError(w, http.StatusForbidden, errors.New("token does not grant access to this owner"))
+		return
+	}
+	rmds, err := s.l.GetRemindersByOwner(owner)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if rmds == nil {
+		rmds = []later.SavedReminder{}
+	}
+	writeJSON(w, http.StatusOK, rmds)
+}
+
+type updateReminderRequest struct {
+	FireTime     *time.Time `json:"fireTime"`
+	CallbackData *string    `json:"callbackData"`
+	// Tags replace the reminder's tags if they're given, even if empty.
+	Tags *[]string `json:"tags"`
+}
+
+func (s *Server) updateReminder(w http.ResponseWriter, r *http.Request, owner string) {
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var req updateReminderRequest
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	existing, found, err := s.l.GetReminderWithOwner(owner, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !found {
+		writeError(w, http.StatusNotFound, errors.New("reminder not found"))
+		return
+	}
+	if req.FireTime != nil {
+		existing.FireTime = *req.FireTime
+	}
+	if req.CallbackData != nil {
+		existing.CallbackData = *req.CallbackData
+	}
+	if req.Tags != nil {
+		if existing.Tags, err = later.NormalizeTags(*req.Tags); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+	updated, err := s.l.UpdateReminderWithOwner(owner, id, existing.Reminder)
+	if errors.Is(err, later.ErrLimitExceeded) {
+		writeError(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !updated {
+		writeError(w, http.StatusNotFound, errors.New("reminder not found"))
+		return
+	}
+	writeJSON(w, http.StatusOK, existing)
+}
+
+func (s *Server) deleteReminder(w http.ResponseWriter, r *http.Request, owner string) {
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	deleted, err := s.l.DeleteReminderWithOwner(owner, id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if !deleted {
+		writeError(w, http.StatusNotFound, errors.New("reminder not found"))
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	if status >= http.StatusInternalServerError {
+		log.Err(err).Msg("while handling api request")
+	}
+	writeJSON(w, status, errorResponse{err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Err(err).Msg("while writing api response")
+	}
+}
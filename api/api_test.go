@@ -0,0 +1,135 @@
+package api_test
+
+import (
+	"encoding/json"
+	"github.com/henges/later/api"
+	"github.com/henges/later/later"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func newTestServer(t *testing.T) *httptest.Server {
+
+	l, err := later.NewLater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	api.NewServer(l, &api.Config{Tokens: map[string]string{"alex-token": "alex", "sam-token": "sam"}}).Register(mux)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func do(t *testing.T, method, url, token, body string) *http.Response {
+
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { res.Body.Close() })
+	return res
+}
+
+func TestServer(t *testing.T) {
+
+	srv := newTestServer(t)
+
+	res := do(t, http.MethodPost, srv.URL+"/reminders", "", `{"fireTime":"2030-01-01T00:00:00Z","callbackData":"hello"}`)
+	if res.StatusCode != http.StatusUnauthorized {
+		t.Fatal("Expected unauthorized, got", res.StatusCode)
+	}
+
+	res = do(t, http.MethodPost, srv.URL+"/reminders", "alex-token", `{"fireTime":"2030-01-01T00:00:00Z","callbackData":"hello"}`)
+	if res.StatusCode != http.StatusCreated {
+		t.Fatal("Expected created, got", res.StatusCode)
+	}
+	var created later.SavedReminder
+	if err := json.NewDecoder(res.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if created.Owner != "alex" || created.CallbackData != "hello" {
+		t.Errorf("Unexpected reminder %+v", created)
+	}
+
+	res = do(t, http.MethodGet, srv.URL+"/reminders?owner=alex", "sam-token", "")
+	if res.StatusCode != http.StatusForbidden {
+		t.Fatal("Expected forbidden, got", res.StatusCode)
+	}
+
+	res = do(t, http.MethodPatch, srv.URL+"/reminders/1", "alex-token", `{"callbackData":"goodbye"}`)
+	if res.StatusCode != http.StatusOK {
+		t.Fatal("Expected ok, got", res.StatusCode)
+	}
+
+	res = do(t, http.MethodGet, srv.URL+"/reminders?owner=alex", "alex-token", "")
+	if res.StatusCode != http.StatusOK {
+		t.Fatal("Expected ok, got", res.StatusCode)
+	}
+	var listed []later.SavedReminder
+	if err := json.NewDecoder(res.Body).Decode(&listed); err != nil {
+		t.Fatal(err)
+	}
+	if len(listed) != 1 || listed[0].CallbackData != "goodbye" || !listed[0].FireTime.Equal(created.FireTime) {
+		t.Errorf("Unexpected reminders %+v", listed)
+	}
+
+	res = do(t, http.MethodDelete, srv.URL+"/reminders/1", "sam-token", "")
+	if res.StatusCode != http.StatusNotFound {
+		t.Fatal("Expected not found, got", res.StatusCode)
+	}
+	res = do(t, http.MethodDelete, srv.URL+"/reminders/1", "alex-token", "")
+	if res.StatusCode != http.StatusNoContent {
+		t.Fatal("Expected no content, got", res.StatusCode)
+	}
+}
+
+func TestServer_Tags(t *testing.T) {
+
+	srv := newTestServer(t)
+
+	res := do(t, http.MethodPost, srv.URL+"/reminders", "alex-token", `{"fireTime":"2030-01-01T00:00:00Z","callbackData":"hello","tags":["#Work","work","home"]}`)
+	if res.StatusCode != http.StatusCreated {
+		t.Fatal("Expected created, got", res.StatusCode)
+	}
+	var created later.SavedReminder
+	if err := json.NewDecoder(res.Body).Decode(&created); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(created.Tags, []string{"work", "home"}) {
+		t.Errorf("Expected normalized tags, got %v", created.Tags)
+	}
+
+	res = do(t, http.MethodPatch, srv.URL+"/reminders/1", "alex-token", `{"tags":["#Home"]}`)
+	if res.StatusCode != http.StatusOK {
+		t.Fatal("Expected ok, got", res.StatusCode)
+	}
+	var updated later.SavedReminder
+	if err := json.NewDecoder(res.Body).Decode(&updated); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(updated.Tags, []string{"home"}) || updated.CallbackData != "hello" {
+		t.Errorf("Expected only the tags to change, got %+v", updated)
+	}
+	res = do(t, http.MethodPatch, srv.URL+"/reminders/1", "alex-token", `{"tags":["two words"]}`)
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected bad request, got %d", res.StatusCode)
+	}
+
+	for _, tags := range []string{`["two words"]`, `["muchtoolongforatag"]`, `[""]`} {
+		res = do(t, http.MethodPost, srv.URL+"/reminders", "alex-token", `{"fireTime":"2030-01-01T00:00:00Z","callbackData":"hello","tags":`+tags+`}`)
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("Expected bad request for tags %s, got %d", tags, res.StatusCode)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package app
+
+import (
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/henges/later/bot"
+	"github.com/henges/later/later"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	accessKindUser = "user"
+	accessKindChat = "chat"
+)
+
+// AccessControl decides who may use the bot, from the allowlists in the
+// config and the rules recorded by /allow and /deny.
+type AccessControl struct {
+	l      *later.Later
+	users  map[int64]bool
+	chats  map[int64]bool
+	admins map[int64]bool
+}
+
+func toSet(ids []int64) map[int64]bool {
+	ret := make(map[int64]bool, len(ids))
+	for _, id := range ids {
+		ret[id] = true
+	}
+	return ret
+}
+
+func NewAccessControl(l *later.Later, c *bot.Config) *AccessControl {
+	return &AccessControl{
+		l:      l,
+		users:  toSet(c.AllowedUsers),
+		chats:  toSet(c.AllowedChats),
+		admins: toSet(c.Admins),
+	}
+}
+
+func (a *AccessControl) Enabled() bool {
+	return len(a.users) > 0 || len(a.chats) > 0 || len(a.admins) > 0
+}
+
+// adminScopes returns the command menu scopes of the admins' private chats.
+func (a *AccessControl) adminScopes() []gotgbot.BotCommandScope {
+	ret := make([]gotgbot.BotCommandScope, 0, len(a.admins))
+	for id := range a.admins {
+		ret = append(ret, gotgbot.BotCommandScopeChat{ChatId: id})
+	}
+	return ret
+}
+
+func (a *AccessControl) IsAdmin(ctx *gobot.Context) bool {
+	return ctx.EffectiveSender != nil && a.admins[ctx.EffectiveSender.Id()]
+}
+
+// allowed checks a recorded rule for the subject before falling back to the
+// config, so that /deny can override the config.
+func (a *AccessControl) allowed(kind string, id int64, configured map[int64]bool) (bool, bool) {
+
+	allowed, found, err := a.l.GetAccess(kind, id)
+	if err != nil {
+		log.Err(err).Str("kind", kind).Int64("id", id).Msg("while checking access rule")
+	} else if found {
+		return allowed, true
+	}
+	return configured[id], configured[id]
+}
+
+func (a *AccessControl) Allowed(ctx *gobot.Context) bool {
+
+	if !a.Enabled() || a.IsAdmin(ctx) {
+		return true
+	}
+	if ctx.EffectiveSender != nil {
+		if allowed, decided := a.allowed(accessKindUser, ctx.EffectiveSender.Id(), a.users); decided {
+			return allowed
+		}
+	}
+	if ctx.EffectiveChat != nil {
+		if allowed, decided := a.allowed(accessKindChat, ctx.EffectiveChat.Id, a.chats); decided {
+			return allowed
+		}
+	}
+	return false
+}
+
+func (a *AccessControl) Reject(b *gotgbot.Bot, ctx *gobot.Context) error {
+
+	if ctx.EffectiveChat == nil || ctx.EffectiveSender == nil {
+		return nil
+	}
+	return sendMessage(b, ctx.EffectiveChat.Id, tr(userLang(a.l, ctx), msgAccessRejected, ctx.EffectiveSender.Id()))
+}
+
+func (a *AccessControl) Middleware() bot.Middleware {
+	return bot.WithAuth(a.Allowed, a.Reject)
+}
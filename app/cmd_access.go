@@ -0,0 +1,106 @@
+package app
+
+import (
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/henges/later/bot"
+	"strconv"
+	"strings"
+)
+
+func NewAllowCommand(a *AccessControl) bot.Command {
+	v := &SetAccess{a, true}
+	return bot.Command{
+		BotCommand: gotgbot.BotCommand{
+			Command:     "allow",
+			Description: "[user|chat <id>] - Allow a user or chat to use the bot (admins only)",
+		},
+		Scopes: a.adminScopes(),
+		LongDescription: `
+Allow a user or chat to use the bot. With no arguments, allows the current
+chat. Only admins can use this command.
+		`,
+		Descriptions: map[string]string{
+			"ru": "[user|chat <id>] - Разрешить пользователю или чату доступ к боту (только для администраторов)",
+			"pt": "[user|chat <id>] - Permitir que um usuário ou chat use o bot (só administradores)",
+		},
+		LongDescriptions: map[string]string{
+			"ru": "Разрешить пользователю или чату доступ к боту. Без аргументов разрешает текущий чат. Только для администраторов.",
+			"pt": "Permite que um usuário ou chat use o bot. Sem argumentos, permite o chat atual. Só administradores podem usar este comando.",
+		},
+		Func: v.Response,
+	}
+}
+
+func NewDenyCommand(a *AccessControl) bot.Command {
+	v := &SetAccess{a, false}
+	return bot.Command{
+		BotCommand: gotgbot.BotCommand{
+			Command:     "deny",
+			Description: "[user|chat <id>] - Stop a user or chat from using the bot (admins only)",
+		},
+		Scopes: a.adminScopes(),
+		LongDescription: `
+Stop a user or chat from using the bot, even if they are in the configured
+allowlist. With no arguments, denies the current chat. Only admins can use
+this command.
+		`,
+		Descriptions: map[string]string{
+			"ru": "[user|chat <id>] - Запретить пользователю или чату доступ к боту (только для администраторов)",
+			"pt": "[user|chat <id>] - Impedir um usuário ou chat de usar o bot (só administradores)",
+		},
+		LongDescriptions: map[string]string{
+			"ru": "Запретить пользователю или чату доступ к боту, даже если он есть в настроенном списке. Без аргументов запрещает текущий чат. Только для администраторов.",
+			"pt": "Impede um usuário ou chat de usar o bot, mesmo que esteja na lista configurada. Sem argumentos, bloqueia o chat atual. Só administradores podem usar este comando.",
+		},
+		Func: v.Response,
+	}
+}
+
+type SetAccess struct {
+	a     *AccessControl
+	allow bool
+}
+
+func (h *SetAccess) accessCommandFromContext(ctx *gobot.Context) (string, int64, error) {
+
+	args := strings.Fields(ctx.EffectiveMessage.Text)[1:]
+	switch len(args) {
+	case 0:
+		return accessKindChat, ctx.EffectiveChat.Id, nil
+	case 2:
+		if args[0] != accessKindUser && args[0] != accessKindChat {
+			return "", 0, fmt.Errorf("for message %s, expected 'user' or 'chat': %w", ctx.EffectiveMessage.Text, ErrInvalidCmd)
+		}
+		id, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return "", 0, fmt.Errorf("for message %s, invalid ID: %w", ctx.EffectiveMessage.Text, ErrInvalidCmd)
+		}
+		return args[0], id, nil
+	default:
+		return "", 0, fmt.Errorf("for message %s, wrong number of arguments: %w", ctx.EffectiveMessage.Text, ErrInvalidCmd)
+	}
+}
+
+func (h *SetAccess) Response(b *gotgbot.Bot, ctx *gobot.Context) error {
+	replyTo := ctx.EffectiveChat.Id
+	lang := userLang(h.a.l, ctx)
+
+	if !h.a.IsAdmin(ctx) {
+		return sendMessage(b, replyTo, tr(lang, msgAccessAdminOnly))
+	}
+	kind, id, err := h.accessCommandFromContext(ctx)
+	if err != nil {
+		bot.Logger(ctx).Err(err).Send()
+		return sendMessage(b, replyTo, escapeValue(err.Error()))
+	}
+	err = h.a.l.SetAccess(kind, id, h.allow)
+	if err != nil {
+		return err
+	}
+	if h.allow {
+		return sendMessage(b, replyTo, tr(lang, msgAccessAllowed, id))
+	}
+	return sendMessage(b, replyTo, tr(lang, msgAccessDenied, id))
+}
@@ -0,0 +1,182 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/henges/later/bot"
+	"github.com/henges/later/later"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+func NewTodayCommand(l *later.Later) bot.Command {
+
+	v := &Agenda{l, msgAgendaToday, 0, 1}
+	return bot.Command{
+		BotCommand: gotgbot.BotCommand{
+			Command:     "today",
+			Description: "Show what's coming up today",
+		},
+		Descriptions: map[string]string{
+			"ru": "Показать напоминания на сегодня",
+			"pt": "Mostrar os lembretes de hoje",
+		},
+		LongDescription: `
+Show the reminders you have left for the rest of today.
+		`,
+		LongDescriptions: map[string]string{
+			"ru": "Показать напоминания, оставшиеся на сегодня.",
+			"pt": "Mostra os lembretes que ainda faltam para hoje.",
+		},
+		Func: v.Response,
+	}
+}
+
+func NewTomorrowCommand(l *later.Later) bot.Command {
+
+	v := &Agenda{l, msgAgendaTomorrow, 1, 1}
+	return bot.Command{
+		BotCommand: gotgbot.BotCommand{
+			Command:     "tomorrow",
+			Description: "Show what's coming up tomorrow",
+		},
+		Descriptions: map[string]string{
+			"ru": "Показать напоминания на завтра",
+			"pt": "Mostrar os lembretes de amanhã",
+		},
+		LongDescription: `
+Show the reminders you have for tomorrow.
+		`,
+		LongDescriptions: map[string]string{
+			"ru": "Показать напоминания на завтра.",
+			"pt": "Mostra os lembretes de amanhã.",
+		},
+		Func: v.Response,
+	}
+}
+
+func NewWeekCommand(l *later.Later) bot.Command {
+
+	v := &Agenda{l, msgAgendaWeek, 0, 7}
+	return bot.Command{
+		BotCommand: gotgbot.BotCommand{
+			Command:     "week",
+			Description: "Show what's coming up in the next 7 days",
+		},
+		Descriptions: map[string]string{
+			"ru": "Показать напоминания на 7 дней вперёд",
+			"pt": "Mostrar os lembretes dos próximos 7 dias",
+		},
+		LongDescription: `
+Show the reminders you have from now until the end of the 7th day from today,
+grouped by day.
+		`,
+		LongDescriptions: map[string]string{
+			"ru": "Показать напоминания с текущего момента до конца седьмого дня, начиная с сегодняшнего, по дням.",
+			"pt": "Mostra os lembretes de agora até o fim do 7º dia a partir de hoje, agrupados por dia.",
+		},
+		Func: v.Response,
+	}
+}
+
+// Agenda shows a user's reminders over a span of days, grouped by day.
+type Agenda struct {
+	l      *later.Later
+	period msgKey
+	// from is the first day shown, counting from today, and days the number
+	// of days shown.
+	from, days int
+}
+
+func (h *Agenda) Response(b *gotgbot.Bot, ctx *gobot.Context) error {
+	user := ctx.EffectiveSender.User.Username
+	replyTo := ctx.EffectiveChat.Id
+	lang := userLang(h.l, ctx)
+
+	start, end := agendaWindow(time.Now().In(tz()), h.from, h.days)
+	rmds, err := remindersBetween(h.l, user, start, end)
+	if err != nil {
+		return err
+	}
+	period := tr(lang, h.period)
+	if len(rmds) == 0 {
+		return sendMessage(b, replyTo, tr(lang, msgAgendaEmpty, user, period))
+	}
+	return sendMessage(b, replyTo, tr(lang, msgAgendaHeader, user, period, formatAgenda(lang, rmds)))
+}
+
+// agendaWindow returns the span of the given days, counting from the day of
+// now. Times before now aren't included.
+func agendaWindow(now time.Time, from, days int) (time.Time, time.Time) {
+
+	y, m, d := now.Date()
+	start := time.Date(y, m, d+from, 0, 0, 0, 0, now.Location())
+	end := time.Date(y, m, d+from+days, 0, 0, 0, 0, now.Location())
+	if start.Before(now) {
+		start = now
+	}
+	return start, end
+}
+
+// remindersBetween returns all of user's reminders from start until end, in
+// chronological order.
+func remindersBetween(l *later.Later, user string, start, end time.Time) ([]later.SavedReminder, error) {
+
+	q := later.Query{Owner: user, From: start, To: end, Limit: later.MaxQueryLimit}
+	var ret []later.SavedReminder
+	for {
+		page, err := l.ListReminders(context.Background(), q)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, page.Reminders...)
+		if page.NextCursor == "" {
+			return ret, nil
+		}
+		q.Cursor = page.NextCursor
+	}
+}
+
+// agendaMaxLength is the most characters of reminders formatAgenda lists,
+// leaving room within Telegram's 4096 for the message around them.
+const agendaMaxLength = 3500
+
+// formatAgenda lists rmds, which must be in chronological order, under a
+// heading for each day. If they don't all fit in agendaMaxLength, the rest
+// are only counted.
+func formatAgenda(lang string, rmds []later.SavedReminder) markdown {
+
+	var sb strings.Builder
+	var lastDay time.Time
+	length := 0
+	for i, rmd := range rmds {
+		var tgcd TelegramCallbackData
+		if err := json.Unmarshal([]byte(rmd.CallbackData), &tgcd); err != nil {
+			continue
+		}
+		var entry strings.Builder
+		t := rmd.FireTime.In(tz())
+		y, m, d := t.Date()
+		day := time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+		if !day.Equal(lastDay) {
+			if sb.Len() > 0 {
+				entry.WriteString("\n")
+			}
+			entry.WriteString(fmt.Sprintf("*%s*\n", formatDay(lang, t)))
+		}
+		entry.WriteString(fmt.Sprintf("%d: __%s__, %s\n", rmd.ID, escapeValue(tgcd.Name), clockFormat(lang, t)))
+		n := utf8.RuneCountInString(entry.String())
+		if length+n > agendaMaxLength {
+			sb.WriteString("\n" + tr(lang, msgAgendaMore, len(rmds)-i))
+			break
+		}
+		sb.WriteString(entry.String())
+		length += n
+		lastDay = day
+	}
+	return markdown(strings.TrimSuffix(sb.String(), "\n"))
+}
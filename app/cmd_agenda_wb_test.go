@@ -0,0 +1,61 @@
+package app
+
+import (
+	"fmt"
+	"github.com/henges/later/later"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestAgendaWindow(t *testing.T) {
+
+	now := time.Date(2024, 10, 18, 15, 30, 0, 0, tz())
+	tcs := []struct {
+		name       string
+		from, days int
+		start, end time.Time
+	}{
+		{"today", 0, 1, now, time.Date(2024, 10, 19, 0, 0, 0, 0, tz())},
+		{"tomorrow", 1, 1, time.Date(2024, 10, 19, 0, 0, 0, 0, tz()), time.Date(2024, 10, 20, 0, 0, 0, 0, tz())},
+		{"week", 0, 7, now, time.Date(2024, 10, 25, 0, 0, 0, 0, tz())},
+	}
+	for _, tc := range tcs {
+		start, end := agendaWindow(now, tc.from, tc.days)
+		if !start.Equal(tc.start) || !end.Equal(tc.end) {
+			t.Errorf("%s: expected %v to %v, got %v to %v", tc.name, tc.start, tc.end, start, end)
+		}
+	}
+}
+
+func TestFormatAgenda(t *testing.T) {
+
+	day := time.Date(2024, 10, 18, 0, 0, 0, 0, tz())
+	rmds := []later.SavedReminder{
+		{ID: 3, Reminder: later.Reminder{FireTime: day.Add(9 * time.Hour), CallbackData: `{"name":"walk"}`}},
+		{ID: 1, Reminder: later.Reminder{FireTime: day.Add(17 * time.Hour), CallbackData: `{"name":"cook"}`}},
+		{ID: 2, Reminder: later.Reminder{FireTime: day.Add(32 * time.Hour), CallbackData: `{"name":"shop"}`}},
+	}
+	expected := "*Fri 18 Oct*\n3: __walk__, 9AM\n1: __cook__, 5PM\n\n*Sat 19 Oct*\n2: __shop__, 8AM"
+	if res := string(formatAgenda("en", rmds)); res != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, res)
+	}
+}
+
+func TestFormatAgenda_TooLong(t *testing.T) {
+
+	day := time.Date(2024, 10, 18, 0, 0, 0, 0, tz())
+	var rmds []later.SavedReminder
+	for i := range 100 {
+		cbd := fmt.Sprintf(`{"name":"%s"}`, strings.Repeat("a", 100))
+		rmds = append(rmds, later.SavedReminder{ID: int64(i), Reminder: later.Reminder{FireTime: day.Add(time.Duration(i) * time.Minute), CallbackData: cbd}})
+	}
+	res := string(formatAgenda("en", rmds))
+	if n := utf8.RuneCountInString(res); n > 4096 {
+		t.Errorf("expected at most 4096 characters, got %d", n)
+	}
+	if !strings.HasSuffix(res, " more, use /list to see them all.") {
+		t.Errorf("expected the rest to be counted, got\n%s", res)
+	}
+}
@@ -0,0 +1,176 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/henges/later/bot"
+	"github.com/henges/later/later"
+	"strconv"
+	"sync"
+	"time"
+)
+
+func NewClearCommand(l *later.Later, u *UndoLog) bot.Command {
+
+	v := &Clear{l, u, newClearStore()}
+	return bot.Command{
+		BotCommand: gotgbot.BotCommand{
+			Command:     "clear",
+			Description: "Delete all your reminders in this chat",
+		},
+		Descriptions: map[string]string{
+			"ru": "Удалить все ваши напоминания в этом чате",
+			"pt": "Apagar todos os seus lembretes neste chat",
+		},
+		LongDescription: `
+Delete all the reminders you've set in this chat, after you confirm how many
+there are. Like /del, this can be undone for a few minutes.
+		`,
+		LongDescriptions: map[string]string{
+			"ru": "Удалить все напоминания, которые вы поставили в этом чате, после того как вы подтвердите их " +
+				"количество. Как и /del, это можно отменить в течение нескольких минут.",
+			"pt": "Apaga todos os lembretes que você criou neste chat, depois que você confirmar quantos são. " +
+				"Como no /del, dá para desfazer por alguns minutos.",
+		},
+		Func:     v.Response,
+		Callback: v.Callback,
+	}
+}
+
+type Clear struct {
+	l       *later.Later
+	u       *UndoLog
+	pending *clearStore
+}
+
+// Callback args of the /clear buttons, which follow the ID of the user who
+// sent /clear and come before the token of the reminders it would delete.
+const (
+	clearConfirmArg = "y"
+	clearCancelArg  = "n"
+)
+
+// clearTTL is how long a /clear waits to be confirmed.
+const clearTTL = 15 * time.Minute
+
+// clearStore holds the reminders each /clear asked to confirm, so confirming
+// deletes exactly those and not ones set since.
+type clearStore struct {
+	mu    sync.Mutex
+	items map[string]deletion
+}
+
+func newClearStore() *clearStore {
+	return &clearStore{items: make(map[string]deletion)}
+}
+
+// add stores the IDs owner is asked to confirm and returns their token.
+func (s *clearStore) add(owner string, ids []int64, now time.Time) string {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range s.items {
+		if now.After(v.expires) {
+			delete(s.items, k)
+		}
+	}
+	token := newToken()
+	s.items[token] = deletion{owner, ids, now.Add(clearTTL)}
+	return token
+}
+
+// take removes and returns owner's IDs stored under token, if they haven't
+// expired.
+func (s *clearStore) take(owner, token string, now time.Time) ([]int64, bool) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.items[token]
+	if !ok || d.owner != owner || now.After(d.expires) {
+		return nil, false
+	}
+	delete(s.items, token)
+	return d.ids, true
+}
+
+// chatReminders returns the IDs of user's reminders that were set in chat.
+func chatReminders(l *later.Later, user string, chat int64) ([]int64, error) {
+
+	rmds, err := remindersBetween(l, user, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	var ids []int64
+	for _, rmd := range rmds {
+		var cbd TelegramCallbackData
+		if err = json.Unmarshal([]byte(rmd.CallbackData), &cbd); err != nil {
+			continue
+		}
+		if cbd.ReplyTo == chat {
+			ids = append(ids, rmd.ID)
+		}
+	}
+	return ids, nil
+}
+
+func (h *Clear) Response(b *gotgbot.Bot, ctx *gobot.Context) error {
+	user := ctx.EffectiveSender.User.Username
+	replyTo := ctx.EffectiveChat.Id
+	lang := userLang(h.l, ctx)
+
+	ids, err := chatReminders(h.l, user, replyTo)
+	if err != nil {
+		return err
+	}
+	if len(ids) == 0 {
+		return sendMessage(b, replyTo, tr(lang, msgClearNone, user))
+	}
+	uid := strconv.FormatInt(ctx.EffectiveSender.Id(), 10)
+	token := h.pending.add(user, ids, time.Now())
+	keyboard := [][]gotgbot.InlineKeyboardButton{{
+		{Text: tr(lang, msgButtonClear, len(ids)), CallbackData: bot.CallbackData("clear", uid, clearConfirmArg, token)},
+		{Text: tr(lang, msgButtonCancel), CallbackData: bot.CallbackData("clear", uid, clearCancelArg, token)},
+	}}
+	return sendMessageWithKeyboard(b, replyTo, tr(lang, msgClearConfirm, user, len(ids)), keyboard)
+}
+
+// Callback handles the buttons of a /clear confirmation. Confirming deletes
+// only the reminders that were counted in it.
+func (h *Clear) Callback(b *gotgbot.Bot, ctx *gobot.Context) error {
+	cq := ctx.CallbackQuery
+	user := cq.From.Username
+	lang := userLang(h.l, ctx)
+
+	args := bot.CallbackArgs(ctx)
+	if len(args) != 3 {
+		return fmt.Errorf("for callback %s, wrong number of arguments: %w", cq.Data, ErrInvalidCmd)
+	}
+	if args[0] != strconv.FormatInt(cq.From.Id, 10) {
+		_, err := cq.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(lang, msgClearNotYours)})
+		return err
+	}
+	if args[1] != clearCancelArg && args[1] != clearConfirmArg {
+		return fmt.Errorf("for callback %s, unexpected arguments: %w", cq.Data, ErrInvalidCmd)
+	}
+	if _, err := cq.Answer(b, nil); err != nil {
+		return err
+	}
+	ids, ok := h.pending.take(user, args[2], time.Now())
+	if args[1] == clearCancelArg {
+		return editMessage(b, cq, tr(lang, msgClearCancelled, user), nil)
+	}
+	if !ok {
+		return editMessage(b, cq, tr(lang, msgClearExpired, user), nil)
+	}
+	deleted, err := h.l.DeleteRemindersWithOwner(user, ids)
+	if err != nil {
+		return err
+	}
+	if len(deleted) == 0 {
+		return editMessage(b, cq, tr(lang, msgClearNone, user), nil)
+	}
+	token := h.u.add(user, deleted, time.Now())
+	return editMessage(b, cq, tr(lang, msgClearDone, user, len(deleted)), undoKeyboard(lang, token))
+}
@@ -0,0 +1,30 @@
+package app
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestClearStore(t *testing.T) {
+
+	now := time.Now()
+	s := newClearStore()
+	token := s.add("alex", []int64{1, 2}, now)
+
+	if _, ok := s.take("sam", token, now); ok {
+		t.Error("expected sam not to be able to take alex's reminders")
+	}
+	ids, ok := s.take("alex", token, now.Add(time.Minute))
+	if !ok || !slices.Equal(ids, []int64{1, 2}) {
+		t.Fatalf("expected IDs [1 2], got %v, %v", ids, ok)
+	}
+	if _, ok = s.take("alex", token, now); ok {
+		t.Error("expected the IDs to have been taken already")
+	}
+
+	token = s.add("alex", []int64{3}, now)
+	if _, ok = s.take("alex", token, now.Add(clearTTL+time.Second)); ok {
+		t.Error("expected the IDs to have expired")
+	}
+}
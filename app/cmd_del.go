@@ -6,74 +6,154 @@ import (
 	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/henges/later/bot"
 	"github.com/henges/later/later"
-	"github.com/olebedev/when"
-	"github.com/rs/zerolog/log"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
+	"unicode"
 )
 
-func NewDeleteReminderCommand(l *later.Later, w *when.Parser) bot.Command {
-	v := &DeleteReminder{l, w}
+func NewDeleteReminderCommand(l *later.Later, p Parsers, u *UndoLog) bot.Command {
+	v := &DeleteReminder{l, p, u}
 	return bot.Command{
 		BotCommand: gotgbot.BotCommand{
 			Command:     "del",
-			Description: "<id> - Delete a reminder",
+			Description: "<id>...|<from>-<to>|#tag - Delete reminders by ID, or all with a tag",
+		},
+		Descriptions: map[string]string{
+			"ru": "<id>...|<от>-<до>|#тег - Удалить напоминания по ID или все с тегом",
+			"pt": "<id>...|<de>-<até>|#tag - Apagar lembretes pelo ID, ou todos com uma tag",
 		},
 		LongDescription: `
 Delete a reminder. The <id> value provided should correspond with a value
-returned by /list.
+returned by /list. Give several IDs to delete them all, like /del 3 5 7, or
+a range, like /del 3-9. Use /del #tag to delete all your reminders with that
+hashtag in their description. Deletions can be undone for a few minutes with
+the Undo button or /undo.
 		`,
+		LongDescriptions: map[string]string{
+			"ru": "Удалить напоминание. Значение <id> должно совпадать с одним из ID, которые показывает /list. " +
+				"Укажите несколько ID, чтобы удалить их все, например /del 3 5 7, или диапазон: /del 3-9. " +
+				"/del #тег удаляет все ваши напоминания с этим хэштегом в описании. Удаление можно отменить " +
+				"в течение нескольких минут кнопкой «Отменить» или командой /undo.",
+			"pt": "Apaga um lembrete. O valor <id> deve corresponder a um dos IDs mostrados por /list. " +
+				"Informe vários IDs para apagar todos, como /del 3 5 7, ou um intervalo, como /del 3-9. " +
+				"Use /del #tag para apagar todos os seus lembretes com essa hashtag na descrição. Dá para desfazer " +
+				"a exclusão por alguns minutos com o botão Desfazer ou com /undo.",
+		},
 		Func: v.Response,
 	}
 }
 
 type DeleteReminder struct {
 	l *later.Later
-	w *when.Parser
+	p Parsers
+	u *UndoLog
 }
 
-func (h *DeleteReminder) deleteReminderCommandFromContext(ctx *gobot.Context) (int64, error) {
+// maxDelIDs is the most reminders one /del can name.
+const maxDelIDs = 100
 
-	s, err := stripCmd(ctx.EffectiveMessage.Text)
-	if err != nil {
-		return 0, err
+// parseIDs parses the reminder IDs given to /del, separated by spaces or
+// commas, where "3-9" stands for 3 to 9 inclusive. Repeated IDs are dropped.
+func parseIDs(s string) ([]int64, error) {
+
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || unicode.IsSpace(r)
+	})
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("for '%s', no IDs given: %w", s, ErrInvalidCmd)
 	}
-	asint, err := strconv.ParseInt(s, 10, 64)
-	if err != nil {
-		return 0, err
+	var ret []int64
+	for _, f := range fields {
+		from, to, isRange := strings.Cut(f, "-")
+		lo, err := strconv.ParseInt(from, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("for '%s': %w", f, ErrInvalidCmd)
+		}
+		hi := lo
+		if isRange {
+			hi, err = strconv.ParseInt(to, 10, 64)
+			if err != nil || hi < lo {
+				return nil, fmt.Errorf("for '%s', invalid range: %w", f, ErrInvalidCmd)
+			}
+		}
+		if hi-lo >= maxDelIDs {
+			return nil, fmt.Errorf("for '%s', more than %d IDs: %w", f, maxDelIDs, ErrInvalidCmd)
+		}
+		// Counting up from lo to hi would overflow if hi were the largest ID.
+		for i := int64(0); i <= hi-lo; i++ {
+			if id := lo + i; !slices.Contains(ret, id) {
+				ret = append(ret, id)
+			}
+		}
+		if len(ret) > maxDelIDs {
+			return nil, fmt.Errorf("for '%s', more than %d IDs: %w", s, maxDelIDs, ErrInvalidCmd)
+		}
+	}
+	return ret, nil
+}
+
+func formatIDs(ids []int64) string {
+
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = strconv.FormatInt(id, 10)
 	}
-	return asint, nil
+	return strings.Join(strs, ", ")
 }
 
 func (h *DeleteReminder) Response(b *gotgbot.Bot, ctx *gobot.Context) error {
-	message := ctx.EffectiveMessage.Text
 	user := ctx.EffectiveSender.User.Username
 	replyTo := ctx.EffectiveChat.Id
+	lang := userLang(h.l, ctx)
 
-	logger := log.With().
-		Str("messageBody", message).
-		Str("username", user).
-		Logger()
-
-	logger.Trace().Msg("Handle update")
-
-	id, err := h.deleteReminderCommandFromContext(ctx)
+	s, err := stripCmd(ctx.EffectiveMessage.Text)
 	if err != nil {
-		logger.Err(err).Send()
-		return err
+		return sendMessage(b, replyTo, tr(lang, msgDelUsage, user))
 	}
-	didDelete, err := h.l.DeleteReminderWithOwner(user, id)
+	if tag, ok := parseTag(s); ok {
+		ids, err := h.l.DeleteRemindersWithOwnerAndTag(user, tag)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			return sendMessage(b, replyTo, tr(lang, msgDelTagDone, user, 0, tag))
+		}
+		token := h.u.add(user, ids, time.Now())
+		return sendMessageWithKeyboard(b, replyTo, tr(lang, msgDelTagDone, user, len(ids), tag), undoKeyboard(lang, token))
+	}
+	ids, err := parseIDs(s)
 	if err != nil {
-		return err
+		return sendMessage(b, replyTo, tr(lang, msgDelUsage, user))
 	}
-	if !didDelete {
-		err = sendMessage(b, replyTo, fmt.Sprintf("@%s, I couldn't find a reminder with ID %d to delete...", user, id))
+	if len(ids) == 1 {
+		return h.deleteOne(b, lang, user, replyTo, ids[0])
+	}
+	deleted, err := h.l.DeleteRemindersWithOwner(user, ids)
+	if err != nil {
 		return err
 	}
+	if len(deleted) == 0 {
+		return sendMessage(b, replyTo, tr(lang, msgDelManyNone, user))
+	}
+	text := tr(lang, msgDelManyDone, user, formatIDs(deleted))
+	if missing := slices.DeleteFunc(ids, func(id int64) bool { return slices.Contains(deleted, id) }); len(missing) > 0 {
+		text += " " + tr(lang, msgDelManyMissing, formatIDs(missing))
+	}
+	token := h.u.add(user, deleted, time.Now())
+	return sendMessageWithKeyboard(b, replyTo, text, undoKeyboard(lang, token))
+}
+
+func (h *DeleteReminder) deleteOne(b *gotgbot.Bot, lang, user string, replyTo, id int64) error {
 
-	resp := fmt.Sprintf("@%s, I successfully deleted the reminder with ID %d. (:", user, id)
-	err = sendMessage(b, replyTo, resp)
+	didDelete, err := h.l.DeleteReminderWithOwner(user, id)
 	if err != nil {
 		return err
 	}
-	return nil
+	if !didDelete {
+		return sendMessage(b, replyTo, tr(lang, msgDelNotFound, user, id))
+	}
+	token := h.u.add(user, []int64{id}, time.Now())
+	return sendMessageWithKeyboard(b, replyTo, tr(lang, msgDelDone, user, id), undoKeyboard(lang, token))
 }
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestParseIDs(t *testing.T) {
+
+	tcs := []struct {
+		in       string
+		expected []int64
+		ok       bool
+	}{
+		{"3", []int64{3}, true},
+		{"3 5 7", []int64{3, 5, 7}, true},
+		{"3,5, 7", []int64{3, 5, 7}, true},
+		{"3-6", []int64{3, 4, 5, 6}, true},
+		{"7 3-5 4", []int64{7, 3, 4, 5}, true},
+		{"5-5", []int64{5}, true},
+		{"9223372036854775807", []int64{math.MaxInt64}, true},
+		{"9223372036854775806-9223372036854775807", []int64{math.MaxInt64 - 1, math.MaxInt64}, true},
+		{"", nil, false},
+		{"abc", nil, false},
+		{"9-3", nil, false},
+		{"3-", nil, false},
+		{"-3", nil, false},
+		{"1-1000", nil, false},
+		{"1-60 61-120", nil, false},
+	}
+	for _, tc := range tcs {
+		res, err := parseIDs(tc.in)
+		if (err == nil) != tc.ok {
+			t.Errorf("for '%s', expected ok=%v, got error %v", tc.in, tc.ok, err)
+			continue
+		}
+		if !slices.Equal(res, tc.expected) {
+			t.Errorf("for '%s', expected %v, got %v", tc.in, tc.expected, res)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+package app
+
+import (
+	"encoding/json"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/henges/later/bot"
+	"github.com/henges/later/later"
+	"github.com/henges/later/metrics"
+	"github.com/rs/zerolog/log"
+	"strings"
+	"time"
+)
+
+func NewDigestCommand(l *later.Later, p Parsers) bot.Command {
+
+	v := &Digest{l, p}
+	return bot.Command{
+		BotCommand: gotgbot.BotCommand{
+			Command:     "digest",
+			Description: "[<time>|off] - Get a summary of your day every day",
+		},
+		Descriptions: map[string]string{
+			"ru": "[<время>|off] - Получать сводку на день каждый день",
+			"pt": "[<horário>|off] - Receber um resumo do seu dia todos os dias",
+		},
+		LongDescription: `
+Get a message every day at the given time, e.g. /digest 7:30am, listing the
+reminders you have that day. It's sent to the chat you set it up in. Use
+/digest off to stop, or /digest on its own to see when it's sent.
+		`,
+		LongDescriptions: map[string]string{
+			"ru": "Каждый день в указанное время, например /digest 7:30, присылать список напоминаний на этот день. " +
+				"Сводка приходит в чат, где её включили. /digest off отключает её, а /digest без аргументов показывает время отправки.",
+			"pt": "Recebe todos os dias no horário indicado, por exemplo /digest 7:30, uma mensagem com os lembretes do dia. " +
+				"Ela é enviada no chat em que foi ativada. Use /digest off para parar, ou só /digest para ver o horário.",
+		},
+		Func: v.Response,
+	}
+}
+
+type Digest struct {
+	l *later.Later
+	p Parsers
+}
+
+// digestSettingKey is the later setting holding the time of day, in clock24
+// format, that a user gets their digest.
+const digestSettingKey = "digest"
+
+// digestKind is the TelegramCallbackData kind of the reminders that send
+// digests.
+const digestKind = "digest"
+
+// digestOwner returns the owner of the reminder that sends user's next
+// digest. Telegram usernames can't contain ':', so it never belongs to a real
+// user.
+func digestOwner(user string) string {
+	return "digest:" + user
+}
+
+func (h *Digest) Response(b *gotgbot.Bot, ctx *gobot.Context) error {
+	user := ctx.EffectiveSender.User.Username
+	replyTo := ctx.EffectiveChat.Id
+	lang := userLang(h.l, ctx)
+	now := time.Now().In(tz())
+
+	s, err := stripCmd(ctx.EffectiveMessage.Text)
+	if err != nil {
+		clock, found, err := h.l.GetSetting(user, digestSettingKey)
+		if err != nil {
+			return err
+		}
+		t, err := time.Parse(clock24, clock)
+		if !found || err != nil {
+			return sendMessage(b, replyTo, tr(lang, msgDigestNone, user))
+		}
+		return sendMessage(b, replyTo, tr(lang, msgDigestCurrent, user, clockFormat(lang, t)))
+	}
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "off") {
+		if err = unscheduleDigest(h.l, user); err != nil {
+			return err
+		}
+		if err = h.l.DeleteSetting(user, digestSettingKey); err != nil {
+			return err
+		}
+		return sendMessage(b, replyTo, tr(lang, msgDigestOff, user))
+	}
+	t, _, err := h.p.parseTimeString(lang, s, now)
+	if err != nil {
+		bot.Logger(ctx).Err(err).Send()
+		return sendMessage(b, replyTo, tr(lang, msgSetBadTime, user, s))
+	}
+	clock := t.Format(clock24)
+	if err = h.l.SetSetting(user, digestSettingKey, clock); err != nil {
+		return err
+	}
+	if err = unscheduleDigest(h.l, user); err != nil {
+		return err
+	}
+	cbd := TelegramCallbackData{ReplyTo: replyTo, Lang: lang, Kind: digestKind}
+	if err = scheduleDigest(h.l, user, cbd, clock, now); err != nil {
+		return err
+	}
+	return sendMessage(b, replyTo, tr(lang, msgDigestSet, user, clockFormat(lang, t)))
+}
+
+// nextDigestTime returns the first time after now at the given clock24 time
+// of day.
+func nextDigestTime(now time.Time, clock string) (time.Time, error) {
+
+	t, err := time.Parse(clock24, clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	y, m, d := now.Date()
+	next := time.Date(y, m, d, t.Hour(), t.Minute(), 0, 0, now.Location())
+	if !next.After(now) {
+		next = time.Date(y, m, d+1, t.Hour(), t.Minute(), 0, 0, now.Location())
+	}
+	return next, nil
+}
+
+// scheduleDigest sets a reminder that sends user's digest at the next clock
+// time after now.
+func scheduleDigest(l *later.Later, user string, cbd TelegramCallbackData, clock string, now time.Time) error {
+
+	next, err := nextDigestTime(now, clock)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(cbd)
+	if err != nil {
+		return err
+	}
+	_, err = l.InsertReminder(later.Reminder{Owner: digestOwner(user), FireTime: next, CallbackData: string(data)})
+	return err
+}
+
+func unscheduleDigest(l *later.Later, user string) error {
+
+	owner := digestOwner(user)
+	rmds, err := l.GetRemindersByOwner(owner)
+	if err != nil {
+		return err
+	}
+	for _, rmd := range rmds {
+		if _, err = l.DeleteReminderWithOwner(owner, rmd.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sendDigest handles a fired digest reminder, sending the user a summary of
+// today's reminders and scheduling tomorrow's digest.
+func sendDigest(l *later.Later, b *gotgbot.Bot, r later.Reminder, cbd TelegramCallbackData) error {
+
+	user := strings.TrimPrefix(r.Owner, digestOwner(""))
+	clock, found, err := l.GetSetting(user, digestSettingKey)
+	if err != nil {
+		metrics.RemindersFailed.Inc()
+		log.Err(err).Str("username", user).Msg("failed getting digest setting")
+		return err
+	}
+	// The digest was turned off since this was scheduled.
+	if !found {
+		return nil
+	}
+	now := time.Now().In(tz())
+	// Schedule the next digest first, so that failing to send this one
+	// doesn't stop them.
+	if err = scheduleDigest(l, user, cbd, clock, now); err != nil {
+		log.Err(err).Str("username", user).Msg("failed scheduling digest")
+	}
+	start, end := agendaWindow(now, 0, 1)
+	rmds, err := remindersBetween(l, user, start, end)
+	if err != nil {
+		metrics.RemindersFailed.Inc()
+		log.Err(err).Str("username", user).Msg("failed getting reminders for digest")
+		return err
+	}
+	text := tr(cbd.Lang, msgDigestEmpty, user)
+	if len(rmds) > 0 {
+		text = tr(cbd.Lang, msgDigest, user, formatAgenda(cbd.Lang, rmds))
+	}
+	if err = sendMessage(b, cbd.ReplyTo, text); err != nil {
+		metrics.RemindersFailed.Inc()
+		log.Err(err).Msg("failed sending digest")
+	}
+	return err
+}
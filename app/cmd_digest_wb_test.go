@@ -0,0 +1,71 @@
+package app
+
+import (
+	"encoding/json"
+	"github.com/henges/later/later"
+	"testing"
+	"time"
+)
+
+func TestNextDigestTime(t *testing.T) {
+
+	now := time.Date(2024, 10, 18, 7, 30, 0, 0, tz())
+	tcs := []struct {
+		clock    string
+		expected time.Time
+	}{
+		{"09:00", time.Date(2024, 10, 18, 9, 0, 0, 0, tz())},
+		{"07:30", time.Date(2024, 10, 19, 7, 30, 0, 0, tz())},
+		{"06:15", time.Date(2024, 10, 19, 6, 15, 0, 0, tz())},
+	}
+	for _, tc := range tcs {
+		res, err := nextDigestTime(now, tc.clock)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.Equal(tc.expected) {
+			t.Errorf("for %s: expected %v, got %v", tc.clock, tc.expected, res)
+		}
+	}
+	if _, err := nextDigestTime(now, "soon"); err == nil {
+		t.Error("expected an error for an invalid clock")
+	}
+}
+
+func TestScheduleDigest(t *testing.T) {
+
+	l, err := later.NewLater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().In(tz())
+	cbd := TelegramCallbackData{ReplyTo: 42, Lang: "en", Kind: digestKind}
+	if err = scheduleDigest(l, "alex", cbd, "08:00", now); err != nil {
+		t.Fatal(err)
+	}
+	rmds, err := l.GetRemindersByOwner(digestOwner("alex"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rmds) != 1 {
+		t.Fatalf("expected 1 digest reminder, got %d", len(rmds))
+	}
+	var saved TelegramCallbackData
+	if err = json.Unmarshal([]byte(rmds[0].CallbackData), &saved); err != nil {
+		t.Fatal(err)
+	}
+	if saved != cbd {
+		t.Errorf("expected callback data %v, got %v", cbd, saved)
+	}
+	// Digest reminders aren't the user's own.
+	if n, _ := l.CountRemindersByOwner("alex"); n != 0 {
+		t.Errorf("expected no reminders for alex, got %d", n)
+	}
+
+	if err = unscheduleDigest(l, "alex"); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := l.CountRemindersByOwner(digestOwner("alex")); n != 0 {
+		t.Errorf("expected the digest to be unscheduled, got %d reminders", n)
+	}
+}
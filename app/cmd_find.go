@@ -0,0 +1,78 @@
+package app
+
+import (
+	"context"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/henges/later/bot"
+	"github.com/henges/later/later"
+	"strings"
+)
+
+func NewFindCommand(l *later.Later) bot.Command {
+
+	v := &FindReminders{l}
+	return bot.Command{
+		BotCommand: gotgbot.BotCommand{
+			Command:     "find",
+			Description: "<words> - Search your reminders",
+		},
+		Descriptions: map[string]string{
+			"ru": "<слова> - Искать среди ваших напоминаний",
+			"pt": "<palavras> - Procurar nos seus lembretes",
+		},
+		LongDescription: `
+Find your reminders whose descriptions have all the given words, or words
+starting with them, e.g. /find dent finds "book the dentist". Reminders that
+have already fired are listed after the pending ones.
+		`,
+		LongDescriptions: map[string]string{
+			"ru": "Найти напоминания, в описании которых есть все указанные слова или слова, которые с них начинаются, " +
+				"например /find зуб найдёт «записаться к зубному». Уже сработавшие напоминания показаны после ожидающих.",
+			"pt": "Encontra os lembretes cuja descrição tem todas as palavras indicadas, ou palavras que começam com elas, " +
+				"por exemplo /find dent encontra \"marcar o dentista\". Os lembretes que já dispararam aparecem depois dos pendentes.",
+		},
+		Func: v.Response,
+	}
+}
+
+type FindReminders struct {
+	l *later.Later
+}
+
+// findLimit is the most reminders /find shows.
+const findLimit = 20
+
+func (h *FindReminders) Response(b *gotgbot.Bot, ctx *gobot.Context) error {
+	user := ctx.EffectiveSender.User.Username
+	replyTo := ctx.EffectiveChat.Id
+	lang := userLang(h.l, ctx)
+
+	s, err := stripCmd(ctx.EffectiveMessage.Text)
+	if err != nil || strings.TrimSpace(s) == "" {
+		return sendMessage(b, replyTo, tr(lang, msgFindUsage, user))
+	}
+	s = strings.TrimSpace(s)
+	page, err := h.l.ListReminders(context.Background(), later.Query{Owner: user, Search: s, Limit: findLimit})
+	if err != nil {
+		return err
+	}
+	past, err := h.l.ListHistory(context.Background(), later.HistoryQuery{Owner: user, Search: s, Limit: findLimit})
+	if err != nil {
+		return err
+	}
+	if len(page.Reminders) == 0 && len(past) == 0 {
+		return sendMessage(b, replyTo, tr(lang, msgFindNone, user, s))
+	}
+	var sections []string
+	if len(page.Reminders) > 0 {
+		sections = append(sections, string(formatReminderList(lang, page.Reminders)))
+	}
+	if page.NextCursor != "" {
+		sections = append(sections, tr(lang, msgFindMore, findLimit))
+	}
+	if len(past) > 0 {
+		sections = append(sections, tr(lang, msgFindPast, formatHistory(lang, past)))
+	}
+	return sendMessage(b, replyTo, tr(lang, msgFindResults, user, s, markdown(strings.Join(sections, "\n\n"))))
+}
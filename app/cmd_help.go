@@ -4,40 +4,59 @@ import (
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/henges/later/bot"
+	"github.com/henges/later/later"
 	"strings"
 )
 
-func formatHelpMessage(cmds []bot.Command) string {
+func localized(lang, def string, translations map[string]string) string {
+	if v, ok := translations[lang]; ok {
+		return v
+	}
+	return def
+}
+
+func formatHelpMessage(lang string, cmds []bot.Command) string {
 
 	var sb strings.Builder
 	for _, cmd := range cmds {
-		text := "*/" + cmd.Command + "*" + " " + cmd.Description + "\n" + makeSingleLine(cmd.LongDescription) + "\n\n"
+		desc := localized(lang, cmd.Description, cmd.Descriptions)
+		longDesc := localized(lang, cmd.LongDescription, cmd.LongDescriptions)
+		text := "*/" + cmd.Command + "*" + " " + desc + "\n" + makeSingleLine(longDesc) + "\n\n"
 		sb.WriteString(text)
 	}
 
 	cmdDescriptions := strings.TrimSpace(sb.String())
-	return botDescription + " These are the available commands:\n\n" + cmdDescriptions
+	return tr(lang, msgHelpIntro, tr(lang, msgBotDescription), markdown(cmdDescriptions))
 }
 
-func NewHelpCommand(cmds []bot.Command) bot.Command {
+func NewHelpCommand(l *later.Later, cmds []bot.Command) bot.Command {
 
-	v := &Help{formatHelpMessage(cmds)}
+	helpMsgs := make(map[string]string, len(catalogs))
+	for lang := range catalogs {
+		helpMsgs[lang] = formatHelpMessage(lang, cmds)
+	}
+	v := &Help{l, helpMsgs}
 	return bot.Command{
 		BotCommand: gotgbot.BotCommand{
 			Command:     "help",
 			Description: "Show help",
 		},
+		Descriptions: map[string]string{
+			"ru": "Показать справку",
+			"pt": "Mostrar ajuda",
+		},
 		Func: v.Response,
 	}
 }
 
 type Help struct {
-	helpMsg string
+	l        *later.Later
+	helpMsgs map[string]string
 }
 
 func (h *Help) Response(b *gotgbot.Bot, ctx *gobot.Context) error {
 	replyTo := ctx.EffectiveChat.Id
-	err := sendMessage(b, replyTo, h.helpMsg)
+	err := sendMessage(b, replyTo, h.helpMsgs[userLang(h.l, ctx)])
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/henges/later/bot"
+	"github.com/henges/later/later"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func NewHistoryCommand(l *later.Later) bot.Command {
+
+	v := &History{l}
+	return bot.Command{
+		BotCommand: gotgbot.BotCommand{
+			Command:     "history",
+			Description: "Show your recently fired reminders",
+		},
+		Descriptions: map[string]string{
+			"ru": "Показать недавно сработавшие напоминания",
+			"pt": "Mostrar os lembretes disparados recentemente",
+		},
+		LongDescription: `
+Show the reminders that fired most recently, and whether each was delivered
+and marked done. Press Done under a reminder when it goes off to mark it done.
+		`,
+		LongDescriptions: map[string]string{
+			"ru": "Показать последние сработавшие напоминания: доставлено ли каждое и отмечено ли как выполненное. " +
+				"Нажмите «Готово» под сработавшим напоминанием, чтобы отметить его выполненным.",
+			"pt": "Mostra os lembretes disparados mais recentemente, e se cada um foi entregue e marcado como feito. " +
+				"Toque em Feito sob um lembrete quando ele disparar para marcá-lo como feito.",
+		},
+		Func:     v.Response,
+		Callback: v.Callback,
+	}
+}
+
+type History struct {
+	l *later.Later
+}
+
+// historyLimit is the most fired reminders /history shows.
+const historyLimit = 10
+
+// doneKeyboard returns the keyboard sent with a fired reminder. Its button
+// identifies the reminder by its ID and fire time, as IDs can be reused once
+// the reminder is gone.
+func doneKeyboard(lang string, r later.SavedReminder) [][]gotgbot.InlineKeyboardButton {
+
+	data := bot.CallbackData("history", strconv.FormatInt(r.ID, 10), strconv.FormatInt(r.FireTime.Unix(), 36))
+	return [][]gotgbot.InlineKeyboardButton{{{Text: tr(lang, msgButtonDone), CallbackData: data}}}
+}
+
+func (h *History) Response(b *gotgbot.Bot, ctx *gobot.Context) error {
+	user := ctx.EffectiveSender.User.Username
+	replyTo := ctx.EffectiveChat.Id
+	lang := userLang(h.l, ctx)
+
+	entries, err := h.l.ListHistory(context.Background(), later.HistoryQuery{Owner: user, Limit: historyLimit})
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return sendMessage(b, replyTo, tr(lang, msgHistoryEmpty, user))
+	}
+	return sendMessage(b, replyTo, tr(lang, msgHistoryHeader, user, formatHistory(lang, entries)))
+}
+
+func formatHistory(lang string, entries []later.HistoryEntry) markdown {
+
+	var sb strings.Builder
+	for _, e := range entries {
+		var cbd TelegramCallbackData
+		if err := json.Unmarshal([]byte(e.CallbackData), &cbd); err != nil {
+			continue
+		}
+		status := msgHistoryDelivered
+		if !e.AcknowledgedAt.IsZero() {
+			status = msgHistoryDone
+		} else if e.Status == later.Failed {
+			status = msgHistoryFailed
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(fmt.Sprintf("__%s__, %s: %s", escapeValue(cbd.Name), formatDate(lang, e.FireTime.In(tz())), tr(lang, status)))
+	}
+	return markdown(sb.String())
+}
+
+// Callback handles the Done button of a fired reminder.
+func (h *History) Callback(b *gotgbot.Bot, ctx *gobot.Context) error {
+	cq := ctx.CallbackQuery
+	lang := userLang(h.l, ctx)
+
+	args := bot.CallbackArgs(ctx)
+	if len(args) != 2 {
+		return fmt.Errorf("for callback %s, wrong number of arguments: %w", cq.Data, ErrInvalidCmd)
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("for callback %s: %w", cq.Data, ErrInvalidCmd)
+	}
+	fireTime, err := strconv.ParseInt(args[1], 36, 64)
+	if err != nil {
+		return fmt.Errorf("for callback %s: %w", cq.Data, ErrInvalidCmd)
+	}
+	acked, err := h.l.AcknowledgeHistory(cq.From.Username, id, time.Unix(fireTime, 0), time.Now())
+	if err != nil {
+		return err
+	}
+	if !acked {
+		_, err = cq.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(lang, msgHistoryCantAck)})
+		return err
+	}
+	if _, err = cq.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(lang, msgHistoryAcked)}); err != nil {
+		return err
+	}
+	if cq.Message == nil {
+		return nil
+	}
+	// An empty keyboard removes the Done button.
+	_, _, err = cq.Message.EditReplyMarkup(b, &gotgbot.EditMessageReplyMarkupOpts{
+		ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: [][]gotgbot.InlineKeyboardButton{}},
+	})
+	if err != nil {
+		countTelegramError(err)
+	}
+	return err
+}
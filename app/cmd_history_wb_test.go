@@ -0,0 +1,33 @@
+package app
+
+import (
+	"github.com/henges/later/later"
+	"testing"
+	"time"
+)
+
+func TestFormatHistory(t *testing.T) {
+
+	day := time.Date(2024, 10, 18, 0, 0, 0, 0, tz())
+	entries := []later.HistoryEntry{
+		{FireTime: day.Add(17 * time.Hour), CallbackData: `{"name":"cook"}`, Status: later.Delivered, AcknowledgedAt: day.Add(18 * time.Hour)},
+		{FireTime: day.Add(9 * time.Hour), CallbackData: `{"name":"walk"}`, Status: later.Failed},
+		{FireTime: day.Add(8 * time.Hour), CallbackData: `{"name":"shop"}`, Status: later.Delivered},
+	}
+	expected := "__cook__, Fri 18 Oct 5PM: done ✓\n__walk__, Fri 18 Oct 9AM: not delivered\n__shop__, Fri 18 Oct 8AM: delivered"
+	if res := string(formatHistory("en", entries)); res != expected {
+		t.Errorf("expected\n%s\ngot\n%s", expected, res)
+	}
+}
+
+func TestDoneKeyboard(t *testing.T) {
+
+	r := later.SavedReminder{ID: 42, Reminder: later.Reminder{FireTime: time.Unix(1729242000, 0)}}
+	kb := doneKeyboard("en", r)
+	if len(kb) != 1 || len(kb[0]) != 1 {
+		t.Fatalf("expected a single button, got %v", kb)
+	}
+	if data := kb[0][0].CallbackData; data != "history:42:sljno0" {
+		t.Errorf("unexpected callback data %q", data)
+	}
+}
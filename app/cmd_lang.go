@@ -0,0 +1,66 @@
+package app
+
+import (
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/henges/later/bot"
+	"github.com/henges/later/later"
+	"strings"
+)
+
+func NewLangCommand(l *later.Later) bot.Command {
+
+	v := &SetLang{l}
+	return bot.Command{
+		BotCommand: gotgbot.BotCommand{
+			Command:     "lang",
+			Description: "[<code>|auto] - Choose the language I talk to you in",
+		},
+		LongDescription: `
+Choose the language I use with you, e.g. /lang ru. Use /lang auto to follow
+your Telegram language settings again, or /lang on its own to see your current
+language.
+		`,
+		Descriptions: map[string]string{
+			"ru": "[<код>|auto] - Выбрать язык общения с ботом",
+			"pt": "[<código>|auto] - Escolher o idioma em que falo com você",
+		},
+		LongDescriptions: map[string]string{
+			"ru": "Выбрать язык, на котором я с вами общаюсь, например /lang pt. /lang auto снова включает настройки языка Telegram, а /lang без аргументов показывает текущий язык.",
+			"pt": "Escolhe o idioma que uso com você, por exemplo /lang ru. Use /lang auto para voltar a seguir as configurações de idioma do Telegram, ou só /lang para ver o idioma atual.",
+		},
+		Func: v.Response,
+	}
+}
+
+type SetLang struct {
+	l *later.Later
+}
+
+func (h *SetLang) Response(b *gotgbot.Bot, ctx *gobot.Context) error {
+	replyTo := ctx.EffectiveChat.Id
+	user := ctx.EffectiveSender.User.Username
+	lang := userLang(h.l, ctx)
+	available := strings.Join(supportedLangs(), ", ")
+
+	args := strings.Fields(ctx.EffectiveMessage.Text)[1:]
+	if len(args) == 0 {
+		return sendMessage(b, replyTo, tr(lang, msgLangCurrent, lang, available))
+	}
+	if strings.EqualFold(args[0], "auto") {
+		err := h.l.DeleteSetting(user, langSettingKey)
+		if err != nil {
+			return err
+		}
+		return sendMessage(b, replyTo, tr(userLang(h.l, ctx), msgLangAuto))
+	}
+	chosen := normalizeLang(args[0])
+	if chosen == "" {
+		return sendMessage(b, replyTo, tr(lang, msgLangUnknown, args[0], available))
+	}
+	err := h.l.SetSetting(user, langSettingKey, chosen)
+	if err != nil {
+		return err
+	}
+	return sendMessage(b, replyTo, tr(chosen, msgLangSet, chosen))
+}
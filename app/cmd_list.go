@@ -1,60 +1,383 @@
 package app
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/henges/later/bot"
 	"github.com/henges/later/later"
-	"github.com/olebedev/when"
-	"github.com/rs/zerolog/log"
+	"slices"
+	"strconv"
+	"time"
 )
 
-func NewListRemindersCommand(l *later.Later, w *when.Parser) bot.Command {
-	v := &ListReminders{l, w}
+func NewListRemindersCommand(l *later.Later, p Parsers, u *UndoLog) bot.Command {
+	v := &ListReminders{l, p, u}
 	return bot.Command{
 		BotCommand: gotgbot.BotCommand{
 			Command:     "list",
 			Description: "List reminders",
 		},
+		Descriptions: map[string]string{
+			"ru": "[#тег] - Список напоминаний",
+			"pt": "[#tag] - Listar lembretes",
+		},
 		LongDescription: `
-List all reminders you have registered. The ID associated with each returned
-reminder can be used to delete a reminder if desired.
+List all reminders you have registered, a page at a time, or with /list #tag,
+only those with that hashtag in their description. Use the buttons under each
+reminder to delete it, change its time or snooze it. The ID associated with
+each returned reminder can also be used with /del.
 		`,
-		Func: v.Response,
+		LongDescriptions: map[string]string{
+			"ru": "Показать все ваши напоминания, по страницам, или с /list #тег только те, в описании которых есть " +
+				"этот хэштег. Кнопки под каждым напоминанием позволяют удалить его, изменить время или отложить. " +
+				"ID каждого напоминания также можно использовать с /del.",
+			"pt": "Lista todos os lembretes que você criou, uma página por vez, ou com /list #tag só os que têm essa " +
+				"hashtag na descrição. Use os botões de cada lembrete para apagá-lo, mudar o horário ou adiá-lo. " +
+				"O ID de cada lembrete também pode ser usado com /del.",
+		},
+
+		Func:     v.Response,
+		Callback: v.Callback,
+		Reply:    v.Reply,
 	}
 }
 
 type ListReminders struct {
 	l *later.Later
-	w *when.Parser
+	p Parsers
+	u *UndoLog
+}
+
+const listPageSize = 5
+
+// Callback args of the /list buttons. Each is followed by the ID of the user
+// the list belongs to, then the arguments noted. A page is given by its number
+// and the cursor it starts after, which is empty for the first page, and the
+// tag the list is filtered by, if any.
+const (
+	listPageArg     = "p" // page, cursor, tag
+	listDeleteArg   = "d" // reminder ID, page, cursor, tag
+	listSnoozeArg   = "s" // reminder ID, page, cursor, tag
+	listSnoozeByArg = "z" // reminder ID, page, snooze key, cursor, tag
+	listEditArg     = "e" // reminder ID
+)
+
+type snoozeOption struct {
+	key   string
+	label msgKey
+	by    time.Duration
+}
+
+var snoozes = []snoozeOption{
+	{"h", msgSnoozeHour, time.Hour},
+	{"d", msgSnoozeDay, 24 * time.Hour},
+	{"w", msgSnoozeWeek, 7 * 24 * time.Hour},
+}
+
+// listEdit is the state of a conversation asking for a reminder's new time.
+type listEdit struct {
+	id int64
 }
 
 func (h *ListReminders) Response(b *gotgbot.Bot, ctx *gobot.Context) error {
-	message := ctx.EffectiveMessage.Text
 	user := ctx.EffectiveSender.User.Username
 	replyTo := ctx.EffectiveChat.Id
+	lang := userLang(h.l, ctx)
 
-	logger := log.With().
-		Str("messageBody", message).
-		Str("username", user).
-		Logger()
+	var tag string
+	if s, err := stripCmd(ctx.EffectiveMessage.Text); err == nil {
+		var ok bool
+		if tag, ok = parseTag(s); !ok {
+			return sendMessage(b, replyTo, tr(lang, msgListUsage, user))
+		}
+	}
+	text, keyboard, err := h.render(lang, user, ctx.EffectiveSender.Id(), tag, 0, "", 0)
+	if err != nil {
+		return err
+	}
+	return sendMessageWithKeyboard(b, replyTo, text, keyboard)
+}
 
-	logger.Trace().Msg("Handle update")
+// render returns the page of the user's reminders starting after the given
+// cursor, and its buttons. If tag isn't empty, only reminders with it are
+// listed. If snoozing isn't zero, that reminder's buttons offer how long to
+// snooze it.
+func (h *ListReminders) render(lang, user string, userID int64, tag string, page int, after string, snoozing int64) (string, [][]gotgbot.InlineKeyboardButton, error) {
 
-	rmds, err := h.l.GetRemindersByOwner(user)
+	ctx := context.Background()
+	q := later.Query{Owner: user, Tag: tag, Limit: listPageSize}
+	res, err := h.l.ListReminders(ctx, withCursor(q, after))
 	if err != nil {
-		logger.Err(err).Send()
+		return "", nil, err
+	}
+	// The page can be left empty by deleting the last reminder on it.
+	if len(res.Reminders) == 0 && after != "" {
+		prev, err := h.cursorBefore(ctx, q, after)
+		if err != nil {
+			return "", nil, err
+		}
+		return h.render(lang, user, userID, tag, max(0, page-1), prev, snoozing)
+	}
+	items := res.Reminders
+	if len(items) == 0 && tag != "" {
+		return tr(lang, msgListTagEmpty, user, tag), nil, nil
+	}
+	if len(items) == 0 {
+		return tr(lang, msgListEmpty, user), nil, nil
+	}
+	total, err := h.l.CountMatching(ctx, q)
+	if err != nil {
+		return "", nil, err
+	}
+	pages := (total + listPageSize - 1) / listPageSize
+	if after == "" {
+		page = 0
+	}
+	page = max(0, min(page, pages-1))
+
+	text := tr(lang, msgListHeader, user, formatReminderList(lang, items))
+	if tag != "" {
+		text = tr(lang, msgListTagHeader, user, tag, formatReminderList(lang, items))
+	}
+	if pages > 1 {
+		text += "\n\n" + tr(lang, msgListPage, page+1, pages)
+	}
+
+	uid := strconv.FormatInt(userID, 10)
+	p := strconv.Itoa(page)
+	var keyboard [][]gotgbot.InlineKeyboardButton
+	for _, rmd := range items {
+		id := strconv.FormatInt(rmd.ID, 10)
+		if rmd.ID == snoozing {
+			var row []gotgbot.InlineKeyboardButton
+			for _, s := range snoozes {
+				row = append(row, gotgbot.InlineKeyboardButton{
+					Text: tr(lang, s.label), CallbackData: bot.CallbackData("list", listSnoozeByArg, uid, id, p, s.key, after, tag),
+				})
+			}
+			row = append(row, gotgbot.InlineKeyboardButton{
+				Text: tr(lang, msgButtonBack), CallbackData: bot.CallbackData("list", listPageArg, uid, p, after, tag),
+			})
+			keyboard = append(keyboard, row)
+			continue
+		}
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{
+			{Text: tr(lang, msgButtonDelete, rmd.ID), CallbackData: bot.CallbackData("list", listDeleteArg, uid, id, p, after, tag)},
+			{Text: tr(lang, msgButtonEdit, rmd.ID), CallbackData: bot.CallbackData("list", listEditArg, uid, id)},
+			{Text: tr(lang, msgButtonSnooze, rmd.ID), CallbackData: bot.CallbackData("list", listSnoozeArg, uid, id, p, after, tag)},
+		})
+	}
+	var nav []gotgbot.InlineKeyboardButton
+	if after != "" {
+		prev, err := h.cursorBefore(ctx, q, after)
+		if err != nil {
+			return "", nil, err
+		}
+		nav = append(nav, gotgbot.InlineKeyboardButton{
+			Text: tr(lang, msgButtonPrev), CallbackData: bot.CallbackData("list", listPageArg, uid, strconv.Itoa(page-1), prev, tag),
+		})
+	}
+	if res.NextCursor != "" {
+		nav = append(nav, gotgbot.InlineKeyboardButton{
+			Text: tr(lang, msgButtonNext), CallbackData: bot.CallbackData("list", listPageArg, uid, strconv.Itoa(page+1), res.NextCursor, tag),
+		})
+	}
+	if len(nav) > 0 {
+		keyboard = append(keyboard, nav)
+	}
+	return text, keyboard, nil
+}
+
+func withCursor(q later.Query, cursor string) later.Query {
+	q.Cursor = cursor
+	return q
+}
+
+// cursorBefore returns the cursor that the page of q before the one starting
+// after the given cursor starts after.
+func (h *ListReminders) cursorBefore(ctx context.Context, q later.Query, after string) (string, error) {
+
+	q.Cursor, q.Order = after, later.Descending
+	res, err := h.l.ListReminders(ctx, q)
+	if err != nil {
+		return "", err
+	}
+	if len(res.Reminders) < listPageSize {
+		return "", nil
+	}
+	return res.Reminders[listPageSize-1].Cursor(), nil
+}
+
+// Callback handles the buttons of a /list message, editing it in place.
+func (h *ListReminders) Callback(b *gotgbot.Bot, ctx *gobot.Context) error {
+	cq := ctx.CallbackQuery
+	user := cq.From.Username
+	lang := userLang(h.l, ctx)
+
+	args := bot.CallbackArgs(ctx)
+	if len(args) < 3 {
+		return fmt.Errorf("for callback %s, wrong number of arguments: %w", cq.Data, ErrInvalidCmd)
+	}
+	if args[1] != strconv.FormatInt(cq.From.Id, 10) {
+		_, err := cq.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(lang, msgListNotYours)})
 		return err
 	}
-	if len(rmds) == 0 {
-		err = sendMessage(b, replyTo, fmt.Sprintf("@%s, you don't currently have any reminders (time to make some).", user))
+	// Numeric arguments come first. Cursors are empty or contain a '.', so
+	// neither they nor anything after them is mistaken for one.
+	nums := make([]int64, 0, len(args)-2)
+	for _, a := range args[2:] {
+		n, err := strconv.ParseInt(a, 10, 64)
+		if err != nil {
+			break
+		}
+		nums = append(nums, n)
+	}
+	strs := args[2+len(nums):]
+
+	var notice string
+	var snoozing int64
+	var page int
+	var after, tag string
+	switch {
+	case args[0] == listPageArg && len(nums) == 1 && len(strs) == 2:
+		page, after, tag = int(nums[0]), strs[0], strs[1]
+	case args[0] == listDeleteArg && len(nums) == 2 && len(strs) == 2:
+		deleted, err := h.l.DeleteReminderWithOwner(user, nums[0])
+		if err != nil {
+			return err
+		}
+		if deleted {
+			h.u.add(user, []int64{nums[0]}, time.Now())
+			notice = tr(lang, msgDelDone, user, nums[0]) + " " + tr(lang, msgUndoHint)
+		} else {
+			notice = tr(lang, msgDelNotFound, user, nums[0])
+		}
+		page, after, tag = int(nums[1]), strs[0], strs[1]
+	case args[0] == listSnoozeArg && len(nums) == 2 && len(strs) == 2:
+		snoozing, page, after, tag = nums[0], int(nums[1]), strs[0], strs[1]
+	case args[0] == listSnoozeByArg && len(nums) == 2 && len(strs) == 3:
+		var err error
+		notice, err = h.snooze(lang, user, nums[0], strs[0])
+		if err != nil {
+			return err
+		}
+		page, after, tag = int(nums[1]), strs[1], strs[2]
+	case args[0] == listEditArg && len(nums) == 1 && len(strs) == 0:
+		return h.askEdit(b, ctx, lang, nums[0])
+	default:
+		return fmt.Errorf("for callback %s, unexpected arguments: %w", cq.Data, ErrInvalidCmd)
+	}
+
+	if _, err := cq.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: notice}); err != nil {
+		return err
+	}
+	text, keyboard, err := h.render(lang, user, cq.From.Id, tag, page, after, snoozing)
+	if errors.Is(err, later.ErrInvalidCursor) {
+		text, keyboard, err = h.render(lang, user, cq.From.Id, tag, 0, "", snoozing)
+	}
+	if err != nil {
+		return err
+	}
+	return editMessage(b, cq, text, keyboard)
+}
+
+// snooze pushes a reminder back by the snooze with the given key, and returns
+// a notice saying what happened.
+func (h *ListReminders) snooze(lang, user string, id int64, key string) (string, error) {
+
+	idx := slices.IndexFunc(snoozes, func(s snoozeOption) bool { return s.key == key })
+	if idx < 0 {
+		return "", fmt.Errorf("unknown snooze %s: %w", key, ErrInvalidCmd)
+	}
+	rmd, found, err := h.l.GetReminderWithOwner(user, id)
+	if err != nil {
+		return "", err
+	}
+	if !found {
+		return tr(lang, msgDelNotFound, user, id), nil
+	}
+	rmd.FireTime = rmd.FireTime.Add(snoozes[idx].by)
+	return h.update(lang, user, rmd)
+}
+
+// update saves a reminder's new time, and returns a message saying what
+// happened.
+func (h *ListReminders) update(lang, user string, rmd later.SavedReminder) (string, error) {
+
+	_, err := h.l.UpdateReminderWithOwner(user, rmd.ID, rmd.Reminder)
+	if errors.Is(err, later.ErrLimitExceeded) {
+		return limitExceededMessage(lang, user, err), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return tr(lang, msgListMoved, rmd.ID, formatDate(lang, rmd.FireTime.In(tz()))), nil
+}
+
+func (h *ListReminders) askEdit(b *gotgbot.Bot, ctx *gobot.Context, lang string, id int64) error {
+	cq := ctx.CallbackQuery
+	user := cq.From.Username
+
+	if _, err := cq.Answer(b, nil); err != nil {
+		return err
+	}
+	rmd, found, err := h.l.GetReminderWithOwner(user, id)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return sendMessage(b, ctx.EffectiveChat.Id, tr(lang, msgDelNotFound, user, id))
+	}
+	var cbd TelegramCallbackData
+	_ = json.Unmarshal([]byte(rmd.CallbackData), &cbd)
+	bot.StartConversation(ctx, listEdit{id})
+	_, err = b.SendMessage(ctx.EffectiveChat.Id, escapeMarkdownV2(tr(lang, msgListAskEdit, user, cbd.Name)), &gotgbot.SendMessageOpts{
+		ParseMode:   "MarkdownV2",
+		ReplyMarkup: gotgbot.ForceReply{ForceReply: true, Selective: true},
+	})
+	if err != nil {
+		countTelegramError(err)
+	}
+	return err
+}
+
+// Reply handles the new time of a reminder being edited.
+func (h *ListReminders) Reply(b *gotgbot.Bot, ctx *gobot.Context) error {
+	user := ctx.EffectiveSender.User.Username
+	replyTo := ctx.EffectiveChat.Id
+	lang := userLang(h.l, ctx)
+	now := time.Now().In(tz())
+
+	state, ok := bot.Conversation(ctx)
+	if !ok {
+		return nil
+	}
+	edit := state.(listEdit)
+	timeString := ctx.EffectiveMessage.Text
+	t, _, err := h.p.parseTimeString(lang, timeString, now)
+	if err != nil {
+		bot.Logger(ctx).Err(err).Send()
+		return sendMessage(b, replyTo, tr(lang, msgSetBadTime, user, timeString))
+	}
+	if !t.After(now) {
+		return sendMessage(b, replyTo, tr(lang, msgSetInPast, user, formatDate(lang, t)))
+	}
+	bot.EndConversation(ctx)
+	rmd, found, err := h.l.GetReminderWithOwner(user, edit.id)
+	if err != nil {
 		return err
 	}
-	resp := fmt.Sprintf("@%s, here are your saved reminders:\n%s", user, formatReminderList(rmds))
-	err = sendMessage(b, replyTo, resp)
+	if !found {
+		return sendMessage(b, replyTo, tr(lang, msgDelNotFound, user, edit.id))
+	}
+	rmd.FireTime = t
+	text, err := h.update(lang, user, rmd)
 	if err != nil {
 		return err
 	}
-	return nil
+	return sendMessage(b, replyTo, text)
 }
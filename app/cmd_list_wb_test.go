@@ -0,0 +1,115 @@
+package app
+
+import (
+	"github.com/henges/later/later"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestListReminders_Render(t *testing.T) {
+
+	l, err := later.NewLater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().Add(time.Hour)
+	for i := range 7 {
+		// Insert in reverse so the IDs aren't in fire time order.
+		_, err = l.InsertReminder(later.Reminder{
+			Owner:        "alex",
+			FireTime:     start.Add(time.Duration(7-i) * time.Hour),
+			CallbackData: `{"name":"r"}`,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := &ListReminders{l: l}
+	rmds, err := l.GetRemindersByOwner("alex")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cursors := make(map[int64]string)
+	for _, r := range rmds {
+		cursors[r.ID] = r.Cursor()
+	}
+
+	text, keyboard, err := h.render("en", "alex", 1, "", 0, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "Page 1 of 2") {
+		t.Errorf("expected page indicator in '%s'", text)
+	}
+	if len(keyboard) != listPageSize+1 {
+		t.Fatalf("expected %d rows, got %d", listPageSize+1, len(keyboard))
+	}
+	if first := keyboard[0][0].Text; first != "Delete 7" {
+		t.Errorf("expected the soonest reminder first, got button '%s'", first)
+	}
+	if nav := keyboard[listPageSize]; len(nav) != 1 || nav[0].CallbackData != "list:p:1:1:"+cursors[3]+":" {
+		t.Errorf("expected only a next button, got %v", nav)
+	}
+
+	text, keyboard, err = h.render("en", "alex", 1, "", 1, cursors[3], 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "Page 2 of 2") || len(keyboard) != 3 {
+		t.Errorf("expected the last page with 2 reminders, got '%s' with %d rows", text, len(keyboard))
+	}
+	if nav := keyboard[2]; len(nav) != 1 || nav[0].CallbackData != "list:p:1:0::" {
+		t.Errorf("expected only a previous button, got %v", nav)
+	}
+
+	_, keyboard, err = h.render("en", "alex", 1, "", 1, cursors[3], 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if row := keyboard[0]; len(row) != len(snoozes)+1 || row[0].CallbackData != "list:z:1:2:1:h:"+cursors[3]+":" {
+		t.Errorf("expected snooze options for reminder 2, got %v", row)
+	}
+
+	// Deleting everything on the last page goes back to the one before.
+	for _, id := range []int64{1, 2} {
+		if _, err = l.DeleteReminderWithOwner("alex", id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	text, keyboard, err = h.render("en", "alex", 1, "", 1, cursors[3], 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(text, "Page") || len(keyboard) != listPageSize || keyboard[0][0].Text != "Delete 7" {
+		t.Errorf("expected the only remaining page, got '%s' with %d rows", text, len(keyboard))
+	}
+
+	_, err = l.InsertReminder(later.Reminder{
+		Owner:        "alex",
+		FireTime:     start,
+		CallbackData: `{"name":"r #work"}`,
+		Tags:         []string{"work"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	text, keyboard, err = h.render("en", "alex", 1, "work", 0, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "tagged #work") || strings.Contains(text, "Page") || len(keyboard) != 1 {
+		t.Errorf("expected only the tagged reminder, got '%s' with %d rows", text, len(keyboard))
+	}
+	if data := keyboard[0][0].CallbackData; !strings.HasSuffix(data, ":work") {
+		t.Errorf("expected the tag in the button data, got %s", data)
+	}
+
+	text, keyboard, err = h.render("en", "sam", 2, "", 0, "", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyboard != nil || !strings.Contains(text, "don't currently have any reminders") {
+		t.Errorf("expected an empty list, got '%s'", text)
+	}
+}
@@ -0,0 +1,242 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/henges/later/bot"
+	"github.com/henges/later/later"
+	"github.com/rs/zerolog/log"
+	"strings"
+	"time"
+)
+
+func NewQuietCommand(l *later.Later) bot.Command {
+
+	v := &Quiet{l}
+	return bot.Command{
+		BotCommand: gotgbot.BotCommand{
+			Command:     "quiet",
+			Description: "[<start>-<end> [silent]|off] - Set quiet hours",
+		},
+		Descriptions: map[string]string{
+			"ru": "[<начало>-<конец> [silent]|off] - Задать тихие часы",
+			"pt": "[<início>-<fim> [silent]|off] - Definir horário de silêncio",
+		},
+		LongDescription: `
+Set hours during which I won't disturb you, e.g. /quiet 22:00-07:00. Reminders
+due in that time are held until it ends, or with /quiet 22:00-07:00 silent,
+sent without a notification. Reminders whose description starts with ! are
+urgent, and always sent as usual. Use /quiet off to remove quiet hours, or
+/quiet on its own to see them.
+		`,
+		LongDescriptions: map[string]string{
+			"ru": "Задать часы, когда я не буду вас беспокоить, например /quiet 22:00-07:00. Напоминания на это время " +
+				"придут, когда тихие часы закончатся, а с /quiet 22:00-07:00 silent — придут без уведомления. Напоминания, " +
+				"описание которых начинается с !, срочные и всегда приходят как обычно. /quiet off отключает тихие часы, " +
+				"а /quiet без аргументов показывает их.",
+			"pt": "Define um horário em que não vou incomodar você, por exemplo /quiet 22:00-07:00. Lembretes desse " +
+				"horário são enviados quando ele termina ou, com /quiet 22:00-07:00 silent, sem notificação. Lembretes " +
+				"cuja descrição começa com ! são urgentes e sempre enviados normalmente. Use /quiet off para remover o " +
+				"horário de silêncio, ou só /quiet para vê-lo.",
+		},
+		Func: v.Response,
+	}
+}
+
+type Quiet struct {
+	l *later.Later
+}
+
+// quietSettingKey is the later setting holding a user's quiet hours, in the
+// format returned by quietHours.String.
+const quietSettingKey = "quiet"
+
+// quietSilent is the argument to /quiet asking for reminders to be sent
+// silently rather than held.
+const quietSilent = "silent"
+
+// quietHours is a daily window, in minutes from midnight, during which a
+// user's reminders are held or sent silently. The window wraps around
+// midnight if end is before start.
+type quietHours struct {
+	start, end int
+	silent     bool
+}
+
+func parseClock(s string) (int, error) {
+
+	for _, layout := range []string{clock24, "15"} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t.Hour()*60 + t.Minute(), nil
+		}
+	}
+	return 0, fmt.Errorf("for time '%s': %w", s, ErrInvalidCmd)
+}
+
+// parseQuietHours parses quiet hours like "22:00-07:00", optionally followed
+// by quietSilent.
+func parseQuietHours(s string) (quietHours, error) {
+
+	fields := strings.Fields(s)
+	if len(fields) == 0 || len(fields) > 2 {
+		return quietHours{}, fmt.Errorf("for quiet hours '%s', wrong number of arguments: %w", s, ErrInvalidCmd)
+	}
+	var ret quietHours
+	if len(fields) == 2 {
+		if !strings.EqualFold(fields[1], quietSilent) {
+			return quietHours{}, fmt.Errorf("for quiet hours '%s', unknown mode: %w", s, ErrInvalidCmd)
+		}
+		ret.silent = true
+	}
+	startStr, endStr, ok := strings.Cut(fields[0], "-")
+	if !ok {
+		return quietHours{}, fmt.Errorf("for quiet hours '%s', no '-' found: %w", s, ErrInvalidCmd)
+	}
+	var err error
+	if ret.start, err = parseClock(startStr); err != nil {
+		return quietHours{}, err
+	}
+	if ret.end, err = parseClock(endStr); err != nil {
+		return quietHours{}, err
+	}
+	if ret.start == ret.end {
+		return quietHours{}, fmt.Errorf("for quiet hours '%s', start and end are the same: %w", s, ErrInvalidCmd)
+	}
+	return ret, nil
+}
+
+func (q quietHours) String() string {
+
+	ret := fmt.Sprintf("%02d:%02d-%02d:%02d", q.start/60, q.start%60, q.end/60, q.end%60)
+	if q.silent {
+		ret += " " + quietSilent
+	}
+	return ret
+}
+
+// contains returns whether t is within the quiet hours.
+func (q quietHours) contains(t time.Time) bool {
+
+	m := t.Hour()*60 + t.Minute()
+	if q.start < q.end {
+		return m >= q.start && m < q.end
+	}
+	return m >= q.start || m < q.end
+}
+
+// endAfter returns the first end of the quiet hours after t.
+func (q quietHours) endAfter(t time.Time) time.Time {
+
+	y, m, d := t.Date()
+	end := time.Date(y, m, d, 0, q.end, 0, 0, t.Location())
+	if !end.After(t) {
+		end = time.Date(y, m, d+1, 0, q.end, 0, 0, t.Location())
+	}
+	return end
+}
+
+// clocks returns the start and end of the quiet hours formatted for lang.
+func (q quietHours) clocks(lang string) (string, string) {
+
+	midnight := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	return clockFormat(lang, midnight.Add(time.Duration(q.start)*time.Minute)),
+		clockFormat(lang, midnight.Add(time.Duration(q.end)*time.Minute))
+}
+
+// getQuietHours returns user's quiet hours, and whether they've set any.
+func getQuietHours(l *later.Later, user string) (quietHours, bool, error) {
+
+	v, found, err := l.GetSetting(user, quietSettingKey)
+	if err != nil || !found {
+		return quietHours{}, false, err
+	}
+	q, err := parseQuietHours(v)
+	if err != nil {
+		return quietHours{}, false, err
+	}
+	return q, true, nil
+}
+
+// isUrgent returns whether a reminder's description marks it as urgent.
+func isUrgent(name string) bool {
+	return strings.HasPrefix(strings.TrimSpace(name), "!")
+}
+
+// quietUntil returns when the reminder r, firing at now, should be sent
+// instead, if its owner has quiet hours and it should be held. If it should be
+// sent silently instead, it returns the zero time.
+func quietUntil(l *later.Later, r later.Reminder, now time.Time) (time.Time, bool) {
+
+	var cbd TelegramCallbackData
+	if err := json.Unmarshal([]byte(r.CallbackData), &cbd); err != nil || cbd.Kind != "" || isUrgent(cbd.Name) {
+		return time.Time{}, false
+	}
+	q, found, err := getQuietHours(l, r.Owner)
+	if err != nil {
+		log.Err(err).Str("username", r.Owner).Msg("while getting quiet hours")
+		return time.Time{}, false
+	}
+	now = now.In(tz())
+	if !found || !q.contains(now) {
+		return time.Time{}, false
+	}
+	if q.silent {
+		return time.Time{}, true
+	}
+	return q.endAfter(now), true
+}
+
+// NewQuietHold returns a later.HoldFunc that holds reminders due during
+// their owner's quiet hours until the end of them.
+func NewQuietHold(l *later.Later) later.HoldFunc {
+
+	return func(r later.Reminder, now time.Time) (time.Time, bool) {
+		until, quiet := quietUntil(l, r, now)
+		return until, quiet && !until.IsZero()
+	}
+}
+
+func (h *Quiet) Response(b *gotgbot.Bot, ctx *gobot.Context) error {
+	user := ctx.EffectiveSender.User.Username
+	replyTo := ctx.EffectiveChat.Id
+	lang := userLang(h.l, ctx)
+
+	s, err := stripCmd(ctx.EffectiveMessage.Text)
+	if errors.Is(err, ErrNoCmd) {
+		q, found, err := getQuietHours(h.l, user)
+		if err != nil {
+			return err
+		}
+		if !found {
+			return sendMessage(b, replyTo, tr(lang, msgQuietNone, user))
+		}
+		return sendMessage(b, replyTo, quietMessage(lang, user, q))
+	}
+	if strings.EqualFold(strings.TrimSpace(s), "off") {
+		if err = h.l.DeleteSetting(user, quietSettingKey); err != nil {
+			return err
+		}
+		return sendMessage(b, replyTo, tr(lang, msgQuietOff, user))
+	}
+	q, err := parseQuietHours(s)
+	if err != nil {
+		bot.Logger(ctx).Err(err).Send()
+		return sendMessage(b, replyTo, tr(lang, msgQuietUsage, user))
+	}
+	if err = h.l.SetSetting(user, quietSettingKey, q.String()); err != nil {
+		return err
+	}
+	return sendMessage(b, replyTo, quietMessage(lang, user, q))
+}
+
+func quietMessage(lang, user string, q quietHours) string {
+
+	start, end := q.clocks(lang)
+	if q.silent {
+		return tr(lang, msgQuietSilent, user, start, end)
+	}
+	return tr(lang, msgQuietHeld, user, start, end)
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"github.com/henges/later/later"
+	"testing"
+	"time"
+)
+
+func TestParseQuietHours(t *testing.T) {
+
+	tcs := []struct {
+		in       string
+		expected string
+		err      bool
+	}{
+		{in: "22:00-07:00", expected: "22:00-07:00"},
+		{in: "22-7", expected: "22:00-07:00"},
+		{in: "13:30-14:15 silent", expected: "13:30-14:15 silent"},
+		{in: "9:00-9:00", err: true},
+		{in: "22:00", err: true},
+		{in: "22:00-07:00 loud", err: true},
+		{in: "late-early", err: true},
+	}
+	for _, tc := range tcs {
+		q, err := parseQuietHours(tc.in)
+		if tc.err {
+			if err == nil {
+				t.Errorf("for '%s': expected an error, got %v", tc.in, q)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("for '%s': %v", tc.in, err)
+			continue
+		}
+		if q.String() != tc.expected {
+			t.Errorf("for '%s': expected %s, got %s", tc.in, tc.expected, q)
+		}
+	}
+}
+
+func TestQuietHours(t *testing.T) {
+
+	overnight := quietHours{start: 22 * 60, end: 7 * 60}
+	day := time.Date(2024, 10, 18, 0, 0, 0, 0, tz())
+	tcs := []struct {
+		at       time.Time
+		contains bool
+		end      time.Time
+	}{
+		{day.Add(23 * time.Hour), true, day.Add(31 * time.Hour)},
+		{day.Add(3 * time.Hour), true, day.Add(7 * time.Hour)},
+		{day.Add(7 * time.Hour), false, day.Add(31 * time.Hour)},
+		{day.Add(12 * time.Hour), false, day.Add(31 * time.Hour)},
+	}
+	for _, tc := range tcs {
+		if res := overnight.contains(tc.at); res != tc.contains {
+			t.Errorf("at %v: expected contains to be %v", tc.at, tc.contains)
+		}
+		if res := overnight.endAfter(tc.at); !res.Equal(tc.end) {
+			t.Errorf("at %v: expected end %v, got %v", tc.at, tc.end, res)
+		}
+	}
+}
+
+func TestQuietHold(t *testing.T) {
+
+	l, err := later.NewLater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = l.SetSetting("alex", quietSettingKey, "22:00-07:00"); err != nil {
+		t.Fatal(err)
+	}
+	if err = l.SetSetting("sam", quietSettingKey, "22:00-07:00 silent"); err != nil {
+		t.Fatal(err)
+	}
+	hold := NewQuietHold(l)
+	night := time.Date(2024, 10, 18, 23, 0, 0, 0, tz())
+	morning := time.Date(2024, 10, 19, 7, 0, 0, 0, tz())
+
+	tcs := []struct {
+		name  string
+		r     later.Reminder
+		now   time.Time
+		until time.Time
+	}{
+		{"held", later.Reminder{Owner: "alex", CallbackData: `{"name":"sleep"}`}, night, morning},
+		{"outside quiet hours", later.Reminder{Owner: "alex", CallbackData: `{"name":"sleep"}`}, morning, time.Time{}},
+		{"urgent", later.Reminder{Owner: "alex", CallbackData: `{"name":"! alarm"}`}, night, time.Time{}},
+		{"digest", later.Reminder{Owner: "alex", CallbackData: `{"kind":"digest"}`}, night, time.Time{}},
+		{"silent", later.Reminder{Owner: "sam", CallbackData: `{"name":"sleep"}`}, night, time.Time{}},
+		{"no quiet hours", later.Reminder{Owner: "kim", CallbackData: `{"name":"sleep"}`}, night, time.Time{}},
+	}
+	for _, tc := range tcs {
+		until, held := hold(tc.r, tc.now)
+		if held != !tc.until.IsZero() || !until.Equal(tc.until) {
+			t.Errorf("%s: expected hold until %v, got %v, %v", tc.name, tc.until, until, held)
+		}
+	}
+	if _, quiet := quietUntil(l, tcs[4].r, night); !quiet {
+		t.Error("expected sam's reminder to be sent silently")
+	}
+}
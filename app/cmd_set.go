@@ -8,119 +8,297 @@ import (
 	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/henges/later/bot"
 	"github.com/henges/later/later"
-	"github.com/olebedev/when"
-	"github.com/rs/zerolog/log"
-	"strings"
+	"strconv"
 	"time"
 )
 
-func NewSetReminderCommand(l *later.Later, w *when.Parser) bot.Command {
-	v := &SetReminder{l, w}
+func NewSetReminderCommand(l *later.Later, p Parsers) bot.Command {
+	v := &SetReminder{l, p, newPendingStore()}
 
 	return bot.Command{
 		BotCommand: gotgbot.BotCommand{
 			Command:     "set",
-			Description: "<time string> = <description> - Set a reminder",
+			Description: "<time string> [=] <description> - Set a reminder",
+		},
+		Descriptions: map[string]string{
+			"ru": "<время> [=] <описание> - Создать напоминание",
+			"pt": "<horário> [=] <descrição> - Criar um lembrete",
 		},
 		LongDescription: `
 Set a reminder that will fire at the time specified by the given time string.
 You can use date-time values like '2025-01-11' and '2025-01-11T11:39:00', as
-well as conversational values like 'tomorrow', 'in three days', etc.
+well as conversational values like 'tomorrow', 'in three days', etc. The time
+can go before or after the description; if I can't tell them apart, separate
+them with an '=', like '/set tomorrow 4pm = do the dishes'. Send /set on its
+own and I'll ask for the description and time one at a time.
 		`,
-		Func: v.Response,
+		LongDescriptions: map[string]string{
+			"ru": "Создать напоминание, которое сработает в указанное время. Можно использовать даты вроде " +
+				"'2025-01-11' и '2025-01-11T11:39:00', а также фразы вроде 'завтра в 9', 'через 3 дня' и т. п. " +
+				"Время можно указать до или после описания; если я не смогу их различить, разделите их знаком '='. " +
+				"Отправьте /set без аргументов, и я спрошу описание и время по очереди.",
+			"pt": "Cria um lembrete que dispara no horário indicado. Você pode usar datas como '2025-01-11' e " +
+				"'2025-01-11T11:39:00', e também expressões como 'amanhã às 9', 'daqui a 3 dias' etc. " +
+				"O horário pode vir antes ou depois da descrição; se eu não conseguir separá-los, use um '='. " +
+				"Envie /set sozinho e eu pergunto a descrição e o horário, um de cada vez.",
+		},
+		Func:     v.Response,
+		Callback: v.Callback,
+		Reply:    v.Reply,
 	}
 }
 
 type SetReminder struct {
-	l *later.Later
-	w *when.Parser
+	l       *later.Later
+	p       Parsers
+	pending *pendingStore
 }
 
+// Callback args for the two kinds of buttons /set shows: a reminder preview
+// and the quick picks of a conversation, which are followed by the ID of the
+// user having it.
+const (
+	previewArg   = "p"
+	quickPickArg = "q"
+	// cancelChoice is the callback arg of the Cancel buttons of previews and
+	// quick picks. Their other buttons pass the index of what they choose.
+	cancelChoice = "x"
+)
+
 func (h *SetReminder) Response(b *gotgbot.Bot, ctx *gobot.Context) error {
-	message := ctx.EffectiveMessage.Text
+	lang := userLang(h.l, ctx)
+	now := time.Now().In(tz())
+
+	pr, err := h.setReminderCommandFromMsgContext(ctx, lang, now)
+	if errors.Is(err, ErrNoCmd) {
+		return h.askWhat(b, ctx, lang)
+	}
+	if err != nil {
+		return h.replyError(b, ctx, lang, err)
+	}
+	return h.sendPreview(b, ctx, lang, pr, now)
+}
+
+// replyError explains to the user why their reminder couldn't be parsed or
+// isn't allowed.
+func (h *SetReminder) replyError(b *gotgbot.Bot, ctx *gobot.Context, lang string, err error) error {
 	user := ctx.EffectiveSender.User.Username
 	replyTo := ctx.EffectiveChat.Id
 
-	logger := log.With().
-		Str("messageBody", message).
-		Str("username", user).
-		Logger()
+	bot.Logger(ctx).Err(err).Send()
+	var badTime *badTimeError
+	if errors.As(err, &badTime) {
+		return sendMessage(b, replyTo, tr(lang, msgSetBadTime, user, badTime.timeString))
+	}
+	if errors.Is(err, errAmbiguousTime) {
+		return sendMessage(b, replyTo, tr(lang, msgSetAmbiguous, user))
+	}
+	if errors.Is(err, ErrInvalidCmd) || errors.Is(err, ErrNoCmd) {
+		return sendMessage(b, replyTo, tr(lang, msgSetUsage, user))
+	}
+	if errors.Is(err, later.ErrLimitExceeded) {
+		return sendMessage(b, replyTo, limitExceededMessage(lang, user, err))
+	}
+	return err
+}
 
-	logger.Trace().Msg("Handle update")
+// sendPreview asks the user to confirm the time of a reminder before it's
+// saved.
+func (h *SetReminder) sendPreview(b *gotgbot.Bot, ctx *gobot.Context, lang string, pr pendingReminder, now time.Time) error {
+	user := ctx.EffectiveSender.User.Username
+	replyTo := ctx.EffectiveChat.Id
 
-	var err error
-	reminder, cbd, err := h.setReminderCommandFromMsgContext(ctx)
-	if err != nil {
-		err2 := sendMessage(b, replyTo, err.Error())
-		if err2 != nil {
-			return err2
+	// Alternatives are always in the future, so offer those if they're all
+	// that's left.
+	if !pr.times[0].After(now) {
+		if len(pr.times) == 1 {
+			return sendMessage(b, replyTo, tr(lang, msgSetInPast, user, formatDate(lang, pr.times[0])))
 		}
-		logger.Err(err).Send()
-		return nil
+		pr.times = pr.times[1:]
 	}
-	err = h.l.InsertReminder(reminder)
-	if err != nil {
-		logger.Err(err).Send()
-		return err
+
+	token := h.pending.add(pr, now)
+	return sendMessageWithKeyboard(b, replyTo, tr(lang, msgSetConfirm, user, formatDate(lang, pr.times[0]), pr.cbd.Name),
+		previewKeyboard(lang, token, pr.times))
+}
+
+// previewKeyboard offers to confirm the first of times or cancel, then each
+// of the remaining times in its own row.
+func previewKeyboard(lang, token string, times []time.Time) [][]gotgbot.InlineKeyboardButton {
+
+	ret := [][]gotgbot.InlineKeyboardButton{{
+		{Text: tr(lang, msgButtonConfirm), CallbackData: bot.CallbackData("set", previewArg, token, "0")},
+		{Text: tr(lang, msgButtonCancel), CallbackData: bot.CallbackData("set", previewArg, token, cancelChoice)},
+	}}
+	for i, t := range times[1:] {
+		ret = append(ret, []gotgbot.InlineKeyboardButton{
+			{Text: formatDate(lang, t), CallbackData: bot.CallbackData("set", previewArg, token, strconv.Itoa(i+1))},
+		})
+	}
+	return ret
+}
+
+// Callback handles the buttons of reminder previews and quick picks.
+func (h *SetReminder) Callback(b *gotgbot.Bot, ctx *gobot.Context) error {
+	cq := ctx.CallbackQuery
+
+	args := bot.CallbackArgs(ctx)
+	switch {
+	case len(args) == 3 && args[0] == previewArg:
+		return h.previewCallback(b, ctx, args[1], args[2])
+	case len(args) == 3 && args[0] == quickPickArg:
+		return h.quickPickCallback(b, ctx, args[1], args[2])
+	default:
+		return fmt.Errorf("for callback %s, unexpected arguments: %w", cq.Data, ErrInvalidCmd)
 	}
+}
+
+func (h *SetReminder) previewCallback(b *gotgbot.Bot, ctx *gobot.Context, token, choice string) error {
+	cq := ctx.CallbackQuery
+	lang := userLang(h.l, ctx)
 	now := time.Now().In(tz())
-	err = sendMessage(b, replyTo, fmt.Sprintf("@%s, I'll remind you about __%s__ %s.",
-		user, cbd.Name, getTimeDisplayString(now, reminder.FireTime)))
+
+	pr, ok := h.pending.get(token, now)
+	if !ok {
+		return answerExpired(b, cq, lang)
+	}
+	if pr.userID != cq.From.Id {
+		_, err := cq.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(lang, msgSetNotYours)})
+		return err
+	}
+	h.pending.remove(token)
+	if _, err := cq.Answer(b, nil); err != nil {
+		return err
+	}
+
+	user := pr.reminder.Owner
+	if choice == cancelChoice {
+		return editMessage(b, cq, tr(pr.lang, msgSetCancelled, user, pr.cbd.Name), nil)
+	}
+	i, err := strconv.Atoi(choice)
+	if err != nil || i < 0 || i >= len(pr.times) {
+		return fmt.Errorf("for callback %s, invalid choice: %w", cq.Data, ErrInvalidCmd)
+	}
+	reminder := pr.reminder
+	reminder.FireTime = pr.times[i]
+	text, err := h.save(ctx, pr.lang, reminder, pr.cbd, now)
 	if err != nil {
 		return err
 	}
-	return nil
+	return editMessage(b, cq, text, nil)
 }
 
-func (h *SetReminder) parseTimeString(s string) (time.Time, error) {
-	// some cases that 'when' doesn't get
-	specialCases := []string{time.DateOnly, time.RFC3339, "2006-01-02T15:04:05"}
-	for _, layout := range specialCases {
-		specialCase, err := time.ParseInLocation(layout, s, tz())
-		if err == nil {
-			return specialCase, nil
-		}
+func answerExpired(b *gotgbot.Bot, cq *gotgbot.CallbackQuery, lang string) error {
+
+	_, err := cq.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(lang, msgSetExpired)})
+	if err != nil {
+		return err
 	}
+	return editMessage(b, cq, tr(lang, msgSetExpired), nil)
+}
+
+// save inserts the reminder, unless its time has passed, and returns the
+// reply describing what happened.
+func (h *SetReminder) save(ctx *gobot.Context, lang string, reminder later.Reminder, cbd TelegramCallbackData, now time.Time) (string, error) {
 
-	parse, err := h.w.Parse(s, time.Now().Truncate(time.Second).In(tz()))
+	user := reminder.Owner
+	if !reminder.FireTime.After(now) {
+		return tr(lang, msgSetInPast, user, formatDate(lang, reminder.FireTime)), nil
+	}
+	_, err := h.l.InsertReminder(reminder)
+	if errors.Is(err, later.ErrLimitExceeded) {
+		bot.Logger(ctx).Debug().Err(err).Msg("Reminder rejected by limits")
+		return limitExceededMessage(lang, user, err), nil
+	}
 	if err != nil {
-		return time.Time{}, err
+		return "", err
 	}
-	if parse == nil {
-		return time.Time{}, errors.New("no match found for text")
+	return tr(lang, msgReminderSet, user, cbd.Name, markdown(getTimeDisplayString(lang, now, reminder.FireTime))), nil
+}
+
+func limitExceededMessage(lang, user string, err error) string {
+
+	var tooMany *later.TooManyPendingError
+	var tooFar *later.TooFarAheadError
+	var tooLong *later.DataTooLongError
+	var descTooLong *later.DescriptionTooLongError
+	switch {
+	case errors.As(err, &tooMany):
+		return tr(lang, msgLimitTooMany, user, tooMany.Max)
+	case errors.As(err, &tooFar):
+		return tr(lang, msgLimitTooFar, user, int(tooFar.Max.Hours()/24))
+	case errors.As(err, &tooLong):
+		return tr(lang, msgLimitTooLong, user, tooLong.Max)
+	case errors.As(err, &descTooLong):
+		return tr(lang, msgLimitDescTooLong, user, descTooLong.Max)
+	default:
+		return escapeValue(err.Error())
 	}
+}
+
+// badTimeError is returned when the time string of a /set command can't be
+// parsed.
+type badTimeError struct {
+	timeString string
+}
+
+func (e *badTimeError) Error() string {
+	return fmt.Sprintf("couldn't parse time string '%s'", e.timeString)
+}
 
-	return parse.Time, nil
+func (e *badTimeError) Unwrap() error {
+	return ErrInvalidCmd
 }
 
 // /set tomorrow 4:00pm = do the dishes
-func (h *SetReminder) setReminderCommandFromMsgContext(ctx *gobot.Context) (later.Reminder, TelegramCallbackData, error) {
+// /set tomorrow 4:00pm do the dishes
+// /set do the dishes in 2 hours
+// /set in 2 hours check x=5
+func (h *SetReminder) setReminderCommandFromMsgContext(ctx *gobot.Context, lang string, now time.Time) (pendingReminder, error) {
 
 	s, err := stripCmd(ctx.EffectiveMessage.Text)
 	if err != nil {
-		return later.Reminder{}, TelegramCallbackData{}, err
+		return pendingReminder{}, err
 	}
-	split := strings.SplitN(s, "=", 2)
-	if len(split) != 2 {
-		return later.Reminder{}, TelegramCallbackData{}, fmt.Errorf("for message %s, no equals sign: %w", s, ErrInvalidCmd)
+	timeString, name, err := splitSetText(h.p.For(lang), s, now)
+	if err != nil {
+		return pendingReminder{}, fmt.Errorf("for message %s: %w", s, err)
+	}
+	if timeString == "" || name == "" {
+		return pendingReminder{}, fmt.Errorf("for message %s, missing time or description: %w", s, ErrInvalidCmd)
 	}
-	timeString, name := strings.TrimSpace(split[0]), strings.TrimSpace(split[1])
-	t, err := h.parseTimeString(timeString)
+	return h.newPending(ctx, lang, timeString, name, now)
+}
+
+func (h *SetReminder) newPending(ctx *gobot.Context, lang, timeString, name string, now time.Time) (pendingReminder, error) {
+
+	if err := h.l.CheckDescription(name); err != nil {
+		return pendingReminder{}, err
+	}
+	t, alts, err := h.p.parseTimeString(lang, timeString, now)
 	if err != nil {
-		return later.Reminder{}, TelegramCallbackData{}, fmt.Errorf("for message %s, couldn't parse time string: %w", s, ErrInvalidCmd)
+		return pendingReminder{}, fmt.Errorf("for time string %s: %w", timeString, &badTimeError{timeString})
 	}
 	cbd := TelegramCallbackData{
 		Name:    name,
 		ReplyTo: ctx.EffectiveChat.Id,
+		Lang:    lang,
 	}
 	cbds, err := json.Marshal(cbd)
 	if err != nil {
-		return later.Reminder{}, TelegramCallbackData{}, err
+		return pendingReminder{}, err
 	}
 
-	return later.Reminder{
-		Owner:        ctx.EffectiveSender.User.Username,
-		FireTime:     t,
-		CallbackData: string(cbds),
-	}, cbd, nil
+	return pendingReminder{
+		userID: ctx.EffectiveSender.Id(),
+		lang:   lang,
+		reminder: later.Reminder{
+			Owner:        ctx.EffectiveSender.User.Username,
+			FireTime:     t,
+			CallbackData: string(cbds),
+			Tags:         extractTags(name),
+		},
+		cbd:   cbd,
+		times: append([]time.Time{t}, alts...),
+	}, nil
 }
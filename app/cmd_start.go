@@ -4,28 +4,35 @@ import (
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/henges/later/bot"
+	"github.com/henges/later/later"
 )
 
-func NewStartCommand() bot.Command {
+func NewStartCommand(l *later.Later) bot.Command {
 
-	v := &Start{}
+	v := &Start{l}
 	return bot.Command{
 		BotCommand: gotgbot.BotCommand{
 			Command:     "start",
 			Description: "Start bot interactions",
 		},
+		Scopes: []gotgbot.BotCommandScope{gotgbot.BotCommandScopeAllPrivateChats{}},
+		Descriptions: map[string]string{
+			"ru": "Начать работу с ботом",
+			"pt": "Começar a usar o bot",
+		},
 		Func: v.Response,
 	}
 }
 
-type Start struct{}
-
-var startMsg = "Hi! " + botDescription + "\n" + "Use /help for more details."
+type Start struct {
+	l *later.Later
+}
 
 func (h *Start) Response(b *gotgbot.Bot, ctx *gobot.Context) error {
 	replyTo := ctx.EffectiveChat.Id
+	lang := userLang(h.l, ctx)
 
-	err := sendMessage(b, replyTo, startMsg)
+	err := sendMessage(b, replyTo, tr(lang, msgStart, tr(lang, msgBotDescription)))
 	if err != nil {
 		return err
 	}
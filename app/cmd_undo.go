@@ -0,0 +1,197 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/henges/later/bot"
+	"github.com/henges/later/later"
+	"sync"
+	"time"
+)
+
+// UndoTTL is how long a deletion can be undone for. It has to be shorter than
+// the time deleted reminders are kept for, which the config checks.
+const UndoTTL = 5 * time.Minute
+
+// deletion is a set of reminders deleted at once, which can be undone
+// together.
+type deletion struct {
+	owner   string
+	ids     []int64
+	expires time.Time
+}
+
+// UndoLog remembers recent deletions, so they can be undone with the Undo
+// button sent after them or with /undo.
+type UndoLog struct {
+	mu    sync.Mutex
+	items map[string]deletion
+	// last holds the token of each owner's latest deletion.
+	last map[string]string
+}
+
+func NewUndoLog() *UndoLog {
+	return &UndoLog{items: make(map[string]deletion), last: make(map[string]string)}
+}
+
+// add records that owner deleted the reminders with the given IDs, and
+// returns the token it can be undone with.
+func (u *UndoLog) add(owner string, ids []int64, now time.Time) string {
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for k, v := range u.items {
+		if now.After(v.expires) {
+			delete(u.items, k)
+			if u.last[v.owner] == k {
+				delete(u.last, v.owner)
+			}
+		}
+	}
+	token := newToken()
+	u.items[token] = deletion{owner, ids, now.Add(UndoTTL)}
+	u.last[owner] = token
+	return token
+}
+
+// take removes and returns owner's deletion with the given token, if it
+// hasn't expired.
+func (u *UndoLog) take(owner, token string, now time.Time) (deletion, bool) {
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	d, ok := u.items[token]
+	if !ok || d.owner != owner || now.After(d.expires) {
+		return deletion{}, false
+	}
+	delete(u.items, token)
+	if u.last[owner] == token {
+		delete(u.last, owner)
+	}
+	return d, true
+}
+
+// takeLast is like take, for owner's latest deletion, and also returns its
+// token.
+func (u *UndoLog) takeLast(owner string, now time.Time) (string, deletion, bool) {
+
+	u.mu.Lock()
+	token, ok := u.last[owner]
+	u.mu.Unlock()
+	if !ok {
+		return "", deletion{}, false
+	}
+	d, ok := u.take(owner, token, now)
+	return token, d, ok
+}
+
+// putBack returns a deletion that was taken but couldn't be undone, so it can
+// be tried again with the same token.
+func (u *UndoLog) putBack(token string, d deletion) {
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.items[token] = d
+	if _, ok := u.last[d.owner]; !ok {
+		u.last[d.owner] = token
+	}
+}
+
+func undoKeyboard(lang, token string) [][]gotgbot.InlineKeyboardButton {
+
+	return [][]gotgbot.InlineKeyboardButton{{{Text: tr(lang, msgButtonUndo), CallbackData: bot.CallbackData("undo", token)}}}
+}
+
+func NewUndoCommand(l *later.Later, u *UndoLog) bot.Command {
+
+	v := &Undo{l, u}
+	return bot.Command{
+		BotCommand: gotgbot.BotCommand{
+			Command:     "undo",
+			Description: "Bring back the reminders you last deleted",
+		},
+		Descriptions: map[string]string{
+			"ru": "Вернуть последние удалённые напоминания",
+			"pt": "Trazer de volta os últimos lembretes apagados",
+		},
+		LongDescription: fmt.Sprintf(`
+Bring back the reminder or reminders you deleted last, if it was in the last
+%d minutes. The Undo button sent when you delete something does the same.
+		`, int(UndoTTL.Minutes())),
+		LongDescriptions: map[string]string{
+			"ru": fmt.Sprintf("Вернуть напоминание или напоминания, которые вы удалили последними, если это было "+
+				"не больше %d минут назад. Кнопка «Отменить» после удаления делает то же самое.", int(UndoTTL.Minutes())),
+			"pt": fmt.Sprintf("Traz de volta o lembrete ou lembretes que você apagou por último, se foi nos últimos "+
+				"%d minutos. O botão Desfazer enviado quando você apaga algo faz o mesmo.", int(UndoTTL.Minutes())),
+		},
+		Func:     v.Response,
+		Callback: v.Callback,
+	}
+}
+
+type Undo struct {
+	l *later.Later
+	u *UndoLog
+}
+
+func (h *Undo) Response(b *gotgbot.Bot, ctx *gobot.Context) error {
+	user := ctx.EffectiveSender.User.Username
+	replyTo := ctx.EffectiveChat.Id
+	lang := userLang(h.l, ctx)
+
+	token, d, ok := h.u.takeLast(user, time.Now())
+	if !ok {
+		return sendMessage(b, replyTo, tr(lang, msgUndoNothing, user))
+	}
+	n, err := h.l.RestoreReminders(user, d.ids)
+	if errors.Is(err, later.ErrLimitExceeded) {
+		h.u.putBack(token, d)
+		return sendMessage(b, replyTo, limitExceededMessage(lang, user, err))
+	}
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return sendMessage(b, replyTo, tr(lang, msgUndoNothing, user))
+	}
+	return sendMessage(b, replyTo, tr(lang, msgUndoDone, user, n))
+}
+
+// Callback handles the Undo button sent after a deletion, replacing the
+// message with the outcome.
+func (h *Undo) Callback(b *gotgbot.Bot, ctx *gobot.Context) error {
+	cq := ctx.CallbackQuery
+	user := cq.From.Username
+	lang := userLang(h.l, ctx)
+
+	args := bot.CallbackArgs(ctx)
+	if len(args) != 1 {
+		return fmt.Errorf("for callback %s, wrong number of arguments: %w", cq.Data, ErrInvalidCmd)
+	}
+	d, ok := h.u.take(user, args[0], time.Now())
+	if !ok {
+		_, err := cq.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(lang, msgUndoExpired)})
+		return err
+	}
+	n, err := h.l.RestoreReminders(user, d.ids)
+	if errors.Is(err, later.ErrLimitExceeded) {
+		h.u.putBack(args[0], d)
+		if _, answerErr := cq.Answer(b, nil); answerErr != nil {
+			return answerErr
+		}
+		// Keep the button, so it can be pressed again once there's room.
+		return editMessage(b, cq, limitExceededMessage(lang, user, err), undoKeyboard(lang, args[0]))
+	}
+	if err != nil {
+		return err
+	}
+	if _, err = cq.Answer(b, nil); err != nil {
+		return err
+	}
+	if n == 0 {
+		return editMessage(b, cq, tr(lang, msgUndoNothing, user), nil)
+	}
+	return editMessage(b, cq, tr(lang, msgUndoDone, user, n), nil)
+}
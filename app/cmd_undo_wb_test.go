@@ -0,0 +1,37 @@
+package app
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestUndoLog(t *testing.T) {
+
+	now := time.Now()
+	u := NewUndoLog()
+	first := u.add("alex", []int64{1}, now)
+	second := u.add("alex", []int64{2, 3}, now)
+
+	if _, ok := u.take("sam", first, now); ok {
+		t.Error("sam undid alex's deletion")
+	}
+	token, d, ok := u.takeLast("alex", now.Add(time.Minute))
+	if !ok || token != second || !slices.Equal(d.ids, []int64{2, 3}) {
+		t.Fatalf("expected the last deletion to be undone, got %s, %v, %v", token, d, ok)
+	}
+	if _, ok = u.take("alex", second, now); ok {
+		t.Error("undid the same deletion twice")
+	}
+	u.putBack(second, d)
+	if token, _, ok = u.takeLast("alex", now); !ok || token != second {
+		t.Errorf("expected the deletion put back to be the last again, got %s, %v", token, ok)
+	}
+	if _, ok = u.take("alex", first, now.Add(UndoTTL+time.Second)); ok {
+		t.Error("expected the first deletion to have expired")
+	}
+	d, ok = u.take("alex", first, now)
+	if !ok || !slices.Equal(d.ids, []int64{1}) {
+		t.Errorf("expected the first deletion to be undone by its token, got %v, %v", d, ok)
+	}
+}
@@ -1,21 +1,31 @@
 package app
 
 import (
+	"crypto/rand"
+	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	"github.com/henges/later/later"
+	"github.com/henges/later/metrics"
 	"github.com/rs/zerolog/log"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
-var botDescription = makeSingleLine(`
-This bot allows you to set reminders. Use /set to give it a time and a message, and it'll
-message this chat at that time with your message.
-`)
+// newToken returns a random token for callback data to refer to something
+// the bot holds in memory. Being random, tokens from before a restart don't
+// find something else after it.
+func newToken() string {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return strconv.FormatUint(binary.BigEndian.Uint64(b[:]), 36)
+}
 
 func makeSingleLine(s string) string {
 	return strings.ReplaceAll(strings.TrimSpace(s), "\n", " ")
@@ -25,6 +35,12 @@ var defLoc *time.Location
 
 var locOnce sync.Once
 
+// SetDefaultTimezone sets the timezone used to interpret and display times.
+// It must be called before any commands are handled.
+func SetDefaultTimezone(loc *time.Location) {
+	defLoc = loc
+}
+
 func tz() *time.Location {
 
 	if defLoc == nil {
@@ -39,20 +55,50 @@ func tz() *time.Location {
 	return defLoc
 }
 
-var replacer = strings.NewReplacer(
-	"-", "\\-",
-	"(", "\\(",
-	")", "\\)",
-	".", "\\.",
-	"+", "\\+",
-	"<", "\\<",
-	">", "\\>",
-	"=", "\\=",
-	"!", "\\!")
+// templateSpecials are the MarkdownV2 characters escaped in the templates
+// messages are made from. The rest, like '_' and '*', are left for the
+// templates to format with.
+const templateSpecials = "-().+<>=!#"
 
+// valueSpecials are all the characters MarkdownV2 needs escaped.
+const valueSpecials = "\\_*[]()~`>#+-=|{}.!<"
+
+// markdown is text that's already MarkdownV2, such as a list of reminders,
+// which tr inserts into a template as it is.
+type markdown string
+
+// escapeMarkdownV2 escapes a message made from templates, leaving their
+// formatting and anything already escaped alone.
 func escapeMarkdownV2(text string) string {
 
-	return replacer.Replace(text)
+	var sb strings.Builder
+	escaped := false
+	for _, r := range text {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\':
+			escaped = true
+		case strings.ContainsRune(templateSpecials, r):
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
+
+// escapeValue escapes every MarkdownV2 character in s, so that text from
+// users, like descriptions, tags and usernames, can't be read as formatting.
+func escapeValue(s string) string {
+
+	var sb strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(valueSpecials, r) {
+			sb.WriteRune('\\')
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
 }
 
 func sendMessage(b *gotgbot.Bot, replyTo int64, text string) error {
@@ -61,9 +107,59 @@ func sendMessage(b *gotgbot.Bot, replyTo int64, text string) error {
 	_, err := b.SendMessage(replyTo, text, &gotgbot.SendMessageOpts{
 		ParseMode: "MarkdownV2",
 	})
+	if err != nil {
+		countTelegramError(err)
+	}
 	return err
 }
 
+func sendMessageWithKeyboard(b *gotgbot.Bot, replyTo int64, text string, keyboard [][]gotgbot.InlineKeyboardButton) error {
+
+	text = escapeMarkdownV2(text)
+	_, err := b.SendMessage(replyTo, text, &gotgbot.SendMessageOpts{
+		ParseMode:   "MarkdownV2",
+		ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: keyboard},
+	})
+	if err != nil {
+		countTelegramError(err)
+	}
+	return err
+}
+
+// editMessage replaces the text of the message a callback query came from,
+// and its keyboard with the given one, if any.
+func editMessage(b *gotgbot.Bot, cq *gotgbot.CallbackQuery, text string, keyboard [][]gotgbot.InlineKeyboardButton) error {
+
+	if cq.Message == nil {
+		return nil
+	}
+	text = escapeMarkdownV2(text)
+	_, _, err := cq.Message.EditText(b, text, &gotgbot.EditMessageTextOpts{
+		ParseMode:   "MarkdownV2",
+		ReplyMarkup: gotgbot.InlineKeyboardMarkup{InlineKeyboard: keyboard},
+	})
+	// Pressing a button that doesn't change anything, like Back, isn't an
+	// error.
+	var tgErr *gotgbot.TelegramError
+	if errors.As(err, &tgErr) && strings.Contains(tgErr.Description, "message is not modified") {
+		return nil
+	}
+	if err != nil {
+		countTelegramError(err)
+	}
+	return err
+}
+
+func countTelegramError(err error) {
+
+	var tgErr *gotgbot.TelegramError
+	if errors.As(err, &tgErr) {
+		metrics.TelegramErrors.WithLabelValues(tgErr.Method, strconv.Itoa(tgErr.Code)).Inc()
+		return
+	}
+	metrics.TelegramErrors.WithLabelValues("sendMessage", "").Inc()
+}
+
 var ErrNoCmd = errors.New("no command found")
 
 func stripCmd(s string) (string, error) {
@@ -83,6 +179,10 @@ var ErrInvalidCmd = errors.New("command wasn't valid")
 type TelegramCallbackData struct {
 	Name    string `json:"name"`
 	ReplyTo int64  `json:"replyTo"`
+	Lang    string `json:"lang,omitempty"`
+	// Kind marks reminders the bot sets for itself, like digestKind. It's
+	// empty for users' own reminders.
+	Kind string `json:"kind,omitempty"`
 }
 
 func dayDifference(now time.Time, future time.Time) int {
@@ -97,6 +197,8 @@ func dayDifference(now time.Time, future time.Time) int {
 
 const kitchenSeconds = "3:04:05PM"
 const kitchenHoursOnly = "3PM"
+const clock24 = "15:04"
+const clock24Seconds = "15:04:05"
 
 func kitchenFormat(future time.Time) string {
 
@@ -109,22 +211,52 @@ func kitchenFormat(future time.Time) string {
 	return future.Format(kitchenSeconds)
 }
 
-func getTimeDisplayString(now, future time.Time) string {
+func clockFormat(lang string, future time.Time) string {
+
+	if !clock24Langs[lang] {
+		return kitchenFormat(future)
+	}
+	if future.Second() == 0 {
+		return future.Format(clock24)
+	}
+	return future.Format(clock24Seconds)
+}
+
+// formatDay formats the day of t like "Fri 18 Oct", in lang.
+func formatDay(lang string, t time.Time) string {
+
+	names, ok := calendars[lang]
+	if !ok {
+		names = calendars[defaultLang]
+	}
+	return fmt.Sprintf("%s %d %s", names.weekdays[t.Weekday()], t.Day(), names.months[t.Month()-1])
+}
+
+// formatDate formats t like "Fri 18 Oct 5PM", in lang.
+func formatDate(lang string, t time.Time) string {
+
+	return formatDay(lang, t) + " " + clockFormat(lang, t)
+}
+
+// getTimeDisplayString describes when future is, relative to now. It's left
+// unescaped, as it's made only of templates and times, so it has to be
+// inserted into other messages as markdown.
+func getTimeDisplayString(lang string, now, future time.Time) string {
 
 	dayDiff := dayDifference(now, future)
-	clockFmt := kitchenFormat(future)
+	clockFmt := markdown(clockFormat(lang, future))
 	if dayDiff == 0 {
-		return "today at " + clockFmt
+		return tr(lang, msgTimeToday, clockFmt)
 	} else if dayDiff == 1 {
-		return "tomorrow at " + clockFmt
+		return tr(lang, msgTimeTomorrow, clockFmt)
 	} else if dayDiff <= 7 {
-		return fmt.Sprintf("in %d days at %s", dayDiff, clockFmt)
+		return tr(lang, msgTimeInDays, dayDiff, clockFmt)
 	} else {
-		return fmt.Sprintf("on %s at %s", future.Format(time.DateOnly), clockFmt)
+		return tr(lang, msgTimeOnDate, markdown(future.Format(time.DateOnly)), clockFmt)
 	}
 }
 
-func formatReminderList(rmds []later.SavedReminder) string {
+func formatReminderList(lang string, rmds []later.SavedReminder) markdown {
 
 	referenceTime := time.Now().In(tz())
 	var sb strings.Builder
@@ -138,31 +270,54 @@ func formatReminderList(rmds []later.SavedReminder) string {
 		}
 
 		timeWZone := rmd.FireTime.In(tz())
-		sb.WriteString(fmt.Sprintf("%d: __%s__, %s", rmd.ID, tgcd.Name, getTimeDisplayString(referenceTime, timeWZone)))
+		sb.WriteString(fmt.Sprintf("%d: __%s__, %s", rmd.ID, escapeValue(tgcd.Name), getTimeDisplayString(lang, referenceTime, timeWZone)))
 	}
 
-	return sb.String()
+	return markdown(sb.String())
 }
 
-func getReminderMessage(owner, name string) string {
+func getReminderMessage(lang, owner, name string) string {
 
-	return fmt.Sprintf("@%s, you asked me to remind you about this at this time:\n%s", owner, name)
+	return tr(lang, msgReminderFired, owner, name)
 }
 
-func StartPolling(l *later.Later, b *gotgbot.Bot) error {
+// NewReminderCallback returns a later.Callback that delivers fired reminders
+// to the chat they were set in, with a button to mark them done.
+func NewReminderCallback(l *later.Later, b *gotgbot.Bot) later.Callback {
 
-	return l.StartPoll(func(reminder later.Reminder) {
+	return func(reminder later.SavedReminder) error {
 
 		var cbd TelegramCallbackData
 		err := json.Unmarshal([]byte(reminder.CallbackData), &cbd)
 		if err != nil {
+			metrics.RemindersFailed.Inc()
 			log.Err(err).Str("data", reminder.CallbackData).Msg("invalid callback data")
-			return
+			return err
+		}
+		if cbd.Kind == digestKind {
+			return sendDigest(l, b, reminder.Reminder, cbd)
 		}
-		err = sendMessage(b, cbd.ReplyTo, getReminderMessage(reminder.Owner, cbd.Name))
+		// Held reminders never get here during quiet hours, so any that do
+		// are to be sent silently.
+		_, quiet := quietUntil(l, reminder.Reminder, time.Now())
+		text := escapeMarkdownV2(getReminderMessage(cbd.Lang, reminder.Owner, cbd.Name))
+		_, err = b.SendMessage(cbd.ReplyTo, text, &gotgbot.SendMessageOpts{
+			ParseMode:           "MarkdownV2",
+			DisableNotification: quiet,
+			ReplyMarkup:         gotgbot.InlineKeyboardMarkup{InlineKeyboard: doneKeyboard(cbd.Lang, reminder)},
+		})
 		if err != nil {
+			countTelegramError(err)
+			metrics.RemindersFailed.Inc()
 			log.Err(err).Msg("failed sending message")
-			return
+			return err
 		}
-	}, time.Second)
+		return nil
+	}
+}
+
+func StartPolling(l *later.Later, cb later.Callback, interval time.Duration) error {
+
+	l.SetHold(NewQuietHold(l))
+	return l.StartPoll(cb, interval)
 }
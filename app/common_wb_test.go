@@ -60,10 +60,31 @@ func TestGetTimeDisplayString(t *testing.T) {
 				now = tc.now
 			}
 
-			res := getTimeDisplayString(now, tc.ref)
+			res := getTimeDisplayString("en", now, tc.ref)
 			if res != tc.expected {
 				t.Errorf("Comparison failed, expected '%s', got '%s", tc.expected, res)
 			}
 		})
 	}
 }
+
+func TestEscapeMarkdownV2(t *testing.T) {
+
+	tcs := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"tag with underscore", tr("en", msgListTagEmpty, "sam_smith", "my_tag"),
+			`@sam\_smith, you don't have any reminders tagged \#my\_tag\.`},
+		{"description with markup", tr("en", msgReminderSet, "alex", "pay *rent* [now]", "today at 5PM"),
+			`@alex, I'll remind you about __pay \*rent\* \[now\]__ today at 5PM\.`},
+		{"backslash", tr("en", msgSetBadTime, "alex", `5\pm`), `@alex, I couldn't understand the time '5\\pm'\.`},
+		{"markdown arg", tr("en", msgListHeader, "alex", markdown("1: __a\\_b__")), "@alex, here are your saved reminders:\n1: __a\\_b__"},
+	}
+	for _, tc := range tcs {
+		if res := escapeMarkdownV2(tc.text); res != tc.expected {
+			t.Errorf("%s: expected\n%s\ngot\n%s", tc.name, tc.expected, res)
+		}
+	}
+}
@@ -0,0 +1,558 @@
+package app
+
+import (
+	"fmt"
+	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/henges/later/later"
+	"github.com/rs/zerolog/log"
+	"slices"
+	"strings"
+)
+
+type msgKey string
+
+const (
+	msgBotDescription   msgKey = "botDescription"
+	msgStart            msgKey = "start"
+	msgHelpIntro        msgKey = "helpIntro"
+	msgReminderSet      msgKey = "reminderSet"
+	msgSetUsage         msgKey = "setUsage"
+	msgSetAmbiguous     msgKey = "setAmbiguous"
+	msgSetBadTime       msgKey = "setBadTime"
+	msgSetConfirm       msgKey = "setConfirm"
+	msgSetInPast        msgKey = "setInPast"
+	msgSetExpired       msgKey = "setExpired"
+	msgSetNotYours      msgKey = "setNotYours"
+	msgSetCancelled     msgKey = "setCancelled"
+	msgSetAskWhat       msgKey = "setAskWhat"
+	msgSetAskWhen       msgKey = "setAskWhen"
+	msgSetOrPick        msgKey = "setOrPick"
+	msgPickHour         msgKey = "pickHour"
+	msgPickTonight      msgKey = "pickTonight"
+	msgPickTomorrow     msgKey = "pickTomorrow"
+	msgPickWeek         msgKey = "pickWeek"
+	msgButtonConfirm    msgKey = "buttonConfirm"
+	msgButtonCancel     msgKey = "buttonCancel"
+	msgLimitTooMany     msgKey = "limitTooMany"
+	msgLimitTooFar      msgKey = "limitTooFar"
+	msgLimitTooLong     msgKey = "limitTooLong"
+	msgLimitDescTooLong msgKey = "limitDescTooLong"
+	msgListEmpty        msgKey = "listEmpty"
+	msgListHeader       msgKey = "listHeader"
+	msgListTagHeader    msgKey = "listTagHeader"
+	msgListTagEmpty     msgKey = "listTagEmpty"
+	msgListUsage        msgKey = "listUsage"
+	msgListPage         msgKey = "listPage"
+	msgListNotYours     msgKey = "listNotYours"
+	msgListMoved        msgKey = "listMoved"
+	msgListAskEdit      msgKey = "listAskEdit"
+	msgAgendaHeader     msgKey = "agendaHeader"
+	msgAgendaEmpty      msgKey = "agendaEmpty"
+	msgAgendaToday      msgKey = "agendaToday"
+	msgAgendaTomorrow   msgKey = "agendaTomorrow"
+	msgAgendaWeek       msgKey = "agendaWeek"
+	msgAgendaMore       msgKey = "agendaMore"
+	msgDigest           msgKey = "digest"
+	msgDigestEmpty      msgKey = "digestEmpty"
+	msgDigestCurrent    msgKey = "digestCurrent"
+	msgDigestNone       msgKey = "digestNone"
+	msgDigestSet        msgKey = "digestSet"
+	msgDigestOff        msgKey = "digestOff"
+	msgQuietNone        msgKey = "quietNone"
+	msgQuietHeld        msgKey = "quietHeld"
+	msgQuietSilent      msgKey = "quietSilent"
+	msgQuietOff         msgKey = "quietOff"
+	msgQuietUsage       msgKey = "quietUsage"
+	msgFindUsage        msgKey = "findUsage"
+	msgFindNone         msgKey = "findNone"
+	msgFindResults      msgKey = "findResults"
+	msgFindMore         msgKey = "findMore"
+	msgFindPast         msgKey = "findPast"
+	msgHistoryEmpty     msgKey = "historyEmpty"
+	msgHistoryHeader    msgKey = "historyHeader"
+	msgHistoryDelivered msgKey = "historyDelivered"
+	msgHistoryFailed    msgKey = "historyFailed"
+	msgHistoryDone      msgKey = "historyDone"
+	msgHistoryAcked     msgKey = "historyAcked"
+	msgHistoryCantAck   msgKey = "historyCantAck"
+	msgButtonDone       msgKey = "buttonDone"
+	msgButtonUndo       msgKey = "buttonUndo"
+	msgUndoDone         msgKey = "undoDone"
+	msgUndoNothing      msgKey = "undoNothing"
+	msgUndoExpired      msgKey = "undoExpired"
+	msgUndoHint         msgKey = "undoHint"
+	msgDelUsage         msgKey = "delUsage"
+	msgDelManyDone      msgKey = "delManyDone"
+	msgDelManyMissing   msgKey = "delManyMissing"
+	msgDelManyNone      msgKey = "delManyNone"
+	msgClearNone        msgKey = "clearNone"
+	msgClearConfirm     msgKey = "clearConfirm"
+	msgClearDone        msgKey = "clearDone"
+	msgClearCancelled   msgKey = "clearCancelled"
+	msgClearNotYours    msgKey = "clearNotYours"
+	msgClearExpired     msgKey = "clearExpired"
+	msgButtonClear      msgKey = "buttonClear"
+	msgButtonDelete     msgKey = "buttonDelete"
+	msgButtonEdit       msgKey = "buttonEdit"
+	msgButtonSnooze     msgKey = "buttonSnooze"
+	msgButtonPrev       msgKey = "buttonPrev"
+	msgButtonNext       msgKey = "buttonNext"
+	msgButtonBack       msgKey = "buttonBack"
+	msgSnoozeHour       msgKey = "snoozeHour"
+	msgSnoozeDay        msgKey = "snoozeDay"
+	msgSnoozeWeek       msgKey = "snoozeWeek"
+	msgDelNotFound      msgKey = "delNotFound"
+	msgDelDone          msgKey = "delDone"
+	msgDelTagDone       msgKey = "delTagDone"
+	msgReminderFired    msgKey = "reminderFired"
+	msgAccessRejected   msgKey = "accessRejected"
+	msgAccessAdminOnly  msgKey = "accessAdminOnly"
+	msgAccessAllowed    msgKey = "accessAllowed"
+	msgAccessDenied     msgKey = "accessDenied"
+	msgLangCurrent      msgKey = "langCurrent"
+	msgLangSet          msgKey = "langSet"
+	msgLangAuto         msgKey = "langAuto"
+	msgLangUnknown      msgKey = "langUnknown"
+	msgTimeToday        msgKey = "timeToday"
+	msgTimeTomorrow     msgKey = "timeTomorrow"
+	msgTimeInDays       msgKey = "timeInDays"
+	msgTimeOnDate       msgKey = "timeOnDate"
+)
+
+const defaultLang = "en"
+
+// langSettingKey is the later setting holding a user's chosen language.
+const langSettingKey = "lang"
+
+var catalogs = map[string]map[msgKey]string{
+	"en": {
+		msgBotDescription: makeSingleLine(`
+This bot allows you to set reminders. Use /set to give it a time and a message, and it'll
+message this chat at that time with your message.
+`),
+		msgStart:            "Hi! %s\nUse /help for more details.",
+		msgHelpIntro:        "%s These are the available commands:\n\n%s",
+		msgReminderSet:      "@%s, I'll remind you about __%s__ %s.",
+		msgSetUsage:         "@%s, I didn't understand that. Try something like /set tomorrow 4pm do the dishes.",
+		msgSetAmbiguous:     "@%s, I'm not sure which part of that is the time. Put an = between the time and the description, like /set tomorrow 4pm = do the dishes.",
+		msgSetBadTime:       "@%s, I couldn't understand the time '%s'.",
+		msgSetConfirm:       "@%s, did you mean %s? I'll remind you about __%s__ then.",
+		msgSetInPast:        "@%s, %s has already passed, so I can't remind you then.",
+		msgSetExpired:       "That reminder has expired, please /set it again.",
+		msgSetNotYours:      "Only the person who set this reminder can choose its time.",
+		msgSetCancelled:     "@%s, OK, I won't remind you about __%s__.",
+		msgSetAskWhat:       "@%s, what should I remind you about?",
+		msgSetAskWhen:       "@%s, when should I remind you about __%s__? Type a time, or pick one below.",
+		msgSetOrPick:        "Or pick a time:",
+		msgPickHour:         "In an hour",
+		msgPickTonight:      "Tonight at 8PM",
+		msgPickTomorrow:     "Tomorrow at 9AM",
+		msgPickWeek:         "In a week",
+		msgButtonConfirm:    "Confirm",
+		msgButtonCancel:     "Cancel",
+		msgLimitTooMany:     "@%s, you already have %d reminders waiting, which is as many as I can hold for you. Use /del to remove some first.",
+		msgLimitTooFar:      "@%s, I can only set reminders up to %d days ahead.",
+		msgLimitTooLong:     "@%s, that reminder is too long for me to remember: with its description it takes more than %d bytes. Please shorten the description.",
+		msgLimitDescTooLong: "@%s, that description is too long: it can be at most %d characters.",
+		msgListEmpty:        "@%s, you don't currently have any reminders (time to make some).",
+		msgListHeader:       "@%s, here are your saved reminders:\n%s",
+		msgListPage:         "Page %d of %d",
+		msgListTagHeader:    "@%s, here are your reminders tagged #%s:\n%s",
+		msgListTagEmpty:     "@%s, you don't have any reminders tagged #%s.",
+		msgListUsage:        "@%s, I didn't understand that. Use /list to see all your reminders, or /list #tag to see those with a tag.",
+		msgListNotYours:     "These buttons are for whoever sent /list. Send /list to see your own reminders.",
+		msgListMoved:        "Reminder %d moved to %s.",
+		msgListAskEdit:      "@%s, when should I remind you about __%s__ instead?",
+		msgAgendaHeader:     "@%s, here's what you have coming up %s:\n\n%s",
+		msgAgendaEmpty:      "@%s, you have nothing coming up %s.",
+		msgAgendaToday:      "today",
+		msgAgendaTomorrow:   "tomorrow",
+		msgAgendaWeek:       "in the next 7 days",
+		msgAgendaMore:       "…and %d more, use /list to see them all.",
+		msgDigest:           "@%s, here's your day:\n\n%s",
+		msgDigestEmpty:      "@%s, you have no reminders today.",
+		msgDigestCurrent:    "@%s, I send you a digest of your day at %s. Use /digest off to stop.",
+		msgDigestNone:       "@%s, you're not getting a daily digest. Use /digest with a time, like /digest 7:30am, to get one.",
+		msgDigestSet:        "@%s, done, I'll send you a digest of your day here at %s every day.",
+		msgDigestOff:        "@%s, OK, no more daily digests.",
+		msgQuietNone:        "@%s, you don't have quiet hours. Set them with something like /quiet 22:00-07:00.",
+		msgQuietHeld:        "@%s, your quiet hours are %s to %s. I'll hold reminders due then until they end.",
+		msgQuietSilent:      "@%s, your quiet hours are %s to %s. I'll send reminders due then without a notification.",
+		msgQuietOff:         "@%s, OK, no more quiet hours.",
+		msgQuietUsage:       "@%s, I didn't understand that. Try something like /quiet 22:00-07:00, or /quiet 22:00-07:00 silent.",
+		msgFindUsage:        "@%s, what should I look for? Try something like /find dentist.",
+		msgFindNone:         "@%s, I couldn't find any reminders matching '%s'.",
+		msgFindResults:      "@%s, here's what I found for '%s':\n%s",
+		msgFindMore:         "Only the first %d are shown, try more words to narrow it down.",
+		msgFindPast:         "Already fired:\n%s",
+		msgHistoryEmpty:     "@%s, none of your reminders have fired recently.",
+		msgHistoryHeader:    "@%s, your recently fired reminders:\n%s",
+		msgHistoryDelivered: "delivered",
+		msgHistoryFailed:    "not delivered",
+		msgHistoryDone:      "done ✓",
+		msgHistoryAcked:     "Marked as done ✓",
+		msgHistoryCantAck:   "This reminder can't be marked done, it's someone else's or too old.",
+		msgButtonDone:       "Done ✓",
+		msgButtonUndo:       "Undo",
+		msgUndoDone:         "@%s, I brought back %d reminders.",
+		msgUndoNothing:      "@%s, there's nothing to undo.",
+		msgUndoExpired:      "That can't be undone anymore.",
+		msgUndoHint:         "Use /undo to bring it back.",
+		msgDelUsage:         "@%s, I didn't understand that. Try /del 3, /del 3 5 7, /del 3-9 or /del #tag.",
+		msgDelManyDone:      "@%s, I deleted reminders %s.",
+		msgDelManyMissing:   "I couldn't find %s.",
+		msgDelManyNone:      "@%s, I couldn't find any of those reminders to delete...",
+		msgClearNone:        "@%s, you have no reminders in this chat.",
+		msgClearConfirm:     "@%s, are you sure you want to delete all %d of your reminders in this chat?",
+		msgClearDone:        "@%s, I deleted %d reminders.",
+		msgClearCancelled:   "@%s, OK, I won't delete anything.",
+		msgClearNotYours:    "Only the person who sent /clear can confirm it.",
+		msgClearExpired:     "@%s, that confirmation has expired. Send /clear again.",
+		msgButtonClear:      "Delete %d",
+		msgButtonDelete:     "Delete %d",
+		msgButtonEdit:       "Edit %d",
+		msgButtonSnooze:     "Snooze %d",
+		msgButtonPrev:       "« Prev",
+		msgButtonNext:       "Next »",
+		msgButtonBack:       "Back",
+		msgSnoozeHour:       "+1 hour",
+		msgSnoozeDay:        "+1 day",
+		msgSnoozeWeek:       "+1 week",
+		msgDelNotFound:      "@%s, I couldn't find a reminder with ID %d to delete...",
+		msgDelDone:          "@%s, I successfully deleted the reminder with ID %d. (:",
+		msgDelTagDone:       "@%s, I deleted %d reminders tagged #%s.",
+		msgReminderFired:    "@%s, you asked me to remind you about this at this time:\n%s",
+		msgAccessRejected:   "Sorry, I'm not taking reminders from you yet. If you think you should have access, ask an admin to /allow you - your user ID is %d.",
+		msgAccessAdminOnly:  "Sorry, only admins can change who can use this bot.",
+		msgAccessAllowed:    "Done, %d can now use this bot.",
+		msgAccessDenied:     "Done, %d can no longer use this bot.",
+		msgLangCurrent:      "Your language is %s. Available languages: %s. Use /lang <code> to change it, or /lang auto to follow your Telegram settings.",
+		msgLangSet:          "Done, I'll use %s with you from now on.",
+		msgLangAuto:         "Done, I'll follow your Telegram language settings.",
+		msgLangUnknown:      "Sorry, I don't speak '%s' yet. Available languages: %s.",
+		msgTimeToday:        "today at %s",
+		msgTimeTomorrow:     "tomorrow at %s",
+		msgTimeInDays:       "in %d days at %s",
+		msgTimeOnDate:       "on %s at %s",
+	},
+	"ru": {
+		msgBotDescription: makeSingleLine(`
+Этот бот помогает ставить напоминания. Используйте /set, чтобы указать время и сообщение, и в
+это время бот напишет ваше сообщение в этот чат.
+`),
+		msgStart:            "Привет! %s\nПодробнее: /help.",
+		msgHelpIntro:        "%s Доступные команды:\n\n%s",
+		msgReminderSet:      "@%s, я напомню вам о __%s__ %s.",
+		msgSetUsage:         "@%s, я вас не понял. Попробуйте так: /set завтра в 16 помыть посуду.",
+		msgSetAmbiguous:     "@%s, я не уверен, какая часть сообщения — это время. Поставьте = между временем и описанием, например: /set завтра в 16 = помыть посуду.",
+		msgSetBadTime:       "@%s, я не смог разобрать время «%s».",
+		msgSetConfirm:       "@%s, вы имели в виду %s? Тогда я напомню вам о __%s__.",
+		msgSetInPast:        "@%s, %s уже прошло, поэтому я не могу напомнить вам в это время.",
+		msgSetExpired:       "Это напоминание устарело, пожалуйста, создайте его снова через /set.",
+		msgSetNotYours:      "Выбрать время может только тот, кто создал напоминание.",
+		msgSetCancelled:     "@%s, хорошо, я не буду напоминать вам о __%s__.",
+		msgSetAskWhat:       "@%s, о чём вам напомнить?",
+		msgSetAskWhen:       "@%s, когда напомнить вам о __%s__? Напишите время или выберите его ниже.",
+		msgSetOrPick:        "Или выберите время:",
+		msgPickHour:         "Через час",
+		msgPickTonight:      "Сегодня в 20:00",
+		msgPickTomorrow:     "Завтра в 9:00",
+		msgPickWeek:         "Через неделю",
+		msgButtonConfirm:    "Подтвердить",
+		msgButtonCancel:     "Отмена",
+		msgLimitTooMany:     "@%s, у вас уже %d ожидающих напоминаний, больше я не удержу. Сначала удалите некоторые через /del.",
+		msgLimitTooFar:      "@%s, я могу ставить напоминания не дальше чем на %d дн. вперёд.",
+		msgLimitTooLong:     "@%s, это напоминание слишком длинное: вместе с описанием оно занимает больше %d байт. Пожалуйста, сократите описание.",
+		msgLimitDescTooLong: "@%s, это описание слишком длинное: в нём может быть не больше %d символов.",
+		msgListEmpty:        "@%s, у вас пока нет напоминаний (самое время их создать).",
+		msgListHeader:       "@%s, вот ваши напоминания:\n%s",
+		msgListPage:         "Страница %d из %d",
+		msgListTagHeader:    "@%s, вот ваши напоминания с тегом #%s:\n%s",
+		msgListTagEmpty:     "@%s, у вас нет напоминаний с тегом #%s.",
+		msgListUsage:        "@%s, я вас не понял. /list показывает все ваши напоминания, а /list #тег — только с этим тегом.",
+		msgListNotYours:     "Эти кнопки для того, кто отправил /list. Отправьте /list, чтобы увидеть свои напоминания.",
+		msgListMoved:        "Напоминание %d перенесено на %s.",
+		msgListAskEdit:      "@%s, когда напомнить вам о __%s__ вместо этого?",
+		msgAgendaHeader:     "@%s, вот что у вас %s:\n\n%s",
+		msgAgendaEmpty:      "@%s, у вас ничего нет %s.",
+		msgAgendaToday:      "на сегодня",
+		msgAgendaTomorrow:   "на завтра",
+		msgAgendaWeek:       "на ближайшие 7 дней",
+		msgAgendaMore:       "…и ещё %d, все напоминания есть в /list.",
+		msgDigest:           "@%s, ваш день:\n\n%s",
+		msgDigestEmpty:      "@%s, на сегодня у вас нет напоминаний.",
+		msgDigestCurrent:    "@%s, я присылаю вам сводку на день в %s. /digest off отключает её.",
+		msgDigestNone:       "@%s, вы не получаете ежедневную сводку. Чтобы включить её, укажите время, например /digest 7:30.",
+		msgDigestSet:        "@%s, готово, я буду присылать сюда сводку на день каждый день в %s.",
+		msgDigestOff:        "@%s, хорошо, больше никаких ежедневных сводок.",
+		msgQuietNone:        "@%s, у вас нет тихих часов. Задайте их, например, так: /quiet 22:00-07:00.",
+		msgQuietHeld:        "@%s, ваши тихие часы с %s до %s. Напоминания на это время придут, когда они закончатся.",
+		msgQuietSilent:      "@%s, ваши тихие часы с %s до %s. Напоминания на это время придут без уведомления.",
+		msgQuietOff:         "@%s, хорошо, тихие часы отключены.",
+		msgQuietUsage:       "@%s, я вас не понял. Попробуйте так: /quiet 22:00-07:00 или /quiet 22:00-07:00 silent.",
+		msgFindUsage:        "@%s, что мне искать? Попробуйте так: /find врач.",
+		msgFindNone:         "@%s, я не нашёл напоминаний по запросу «%s».",
+		msgFindResults:      "@%s, вот что я нашёл по запросу «%s»:\n%s",
+		msgFindMore:         "Показаны только первые %d, добавьте слов, чтобы сузить поиск.",
+		msgFindPast:         "Уже сработали:\n%s",
+		msgHistoryEmpty:     "@%s, в последнее время ваши напоминания не срабатывали.",
+		msgHistoryHeader:    "@%s, недавно сработавшие напоминания:\n%s",
+		msgHistoryDelivered: "доставлено",
+		msgHistoryFailed:    "не доставлено",
+		msgHistoryDone:      "выполнено ✓",
+		msgHistoryAcked:     "Отмечено как выполненное ✓",
+		msgHistoryCantAck:   "Это напоминание нельзя отметить: оно чужое или слишком старое.",
+		msgButtonDone:       "Готово ✓",
+		msgButtonUndo:       "Отменить",
+		msgUndoDone:         "@%s, я вернул напоминаний: %d.",
+		msgUndoNothing:      "@%s, отменять нечего.",
+		msgUndoExpired:      "Это уже нельзя отменить.",
+		msgUndoHint:         "Вернуть его можно командой /undo.",
+		msgDelUsage:         "@%s, я вас не понял. Попробуйте так: /del 3, /del 3 5 7, /del 3-9 или /del #тег.",
+		msgDelManyDone:      "@%s, я удалил напоминания %s.",
+		msgDelManyMissing:   "Не нашёл: %s.",
+		msgDelManyNone:      "@%s, я не нашёл ни одного из этих напоминаний...",
+		msgClearNone:        "@%s, у вас нет напоминаний в этом чате.",
+		msgClearConfirm:     "@%s, точно удалить все ваши напоминания в этом чате? Их %d.",
+		msgClearDone:        "@%s, удалено напоминаний: %d.",
+		msgClearCancelled:   "@%s, хорошо, ничего не удаляю.",
+		msgClearNotYours:    "Подтвердить может только тот, кто отправил /clear.",
+		msgClearExpired:     "@%s, это подтверждение устарело. Отправьте /clear ещё раз.",
+		msgButtonClear:      "Удалить %d",
+		msgButtonDelete:     "Удалить %d",
+		msgButtonEdit:       "Изменить %d",
+		msgButtonSnooze:     "Отложить %d",
+		msgButtonPrev:       "« Назад",
+		msgButtonNext:       "Далее »",
+		msgButtonBack:       "Назад",
+		msgSnoozeHour:       "+1 час",
+		msgSnoozeDay:        "+1 день",
+		msgSnoozeWeek:       "+1 неделя",
+		msgDelNotFound:      "@%s, я не нашёл напоминание с ID %d...",
+		msgDelDone:          "@%s, напоминание с ID %d удалено. (:",
+		msgDelTagDone:       "@%[1]s, удалено напоминаний с тегом #%[3]s: %[2]d.",
+		msgReminderFired:    "@%s, вы просили напомнить вам об этом в это время:\n%s",
+		msgAccessRejected:   "Извините, я пока не принимаю от вас напоминания. Если вам нужен доступ, попросите администратора выполнить /allow - ваш ID пользователя %d.",
+		msgAccessAdminOnly:  "Извините, только администраторы могут менять доступ к боту.",
+		msgAccessAllowed:    "Готово, у %d теперь есть доступ к боту.",
+		msgAccessDenied:     "Готово, у %d больше нет доступа к боту.",
+		msgLangCurrent:      "Ваш язык: %s. Доступные языки: %s. Используйте /lang <код>, чтобы сменить его, или /lang auto, чтобы следовать настройкам Telegram.",
+		msgLangSet:          "Готово, теперь я буду использовать язык %s.",
+		msgLangAuto:         "Готово, теперь я следую языковым настройкам Telegram.",
+		msgLangUnknown:      "Извините, я пока не говорю на «%s». Доступные языки: %s.",
+		msgTimeToday:        "сегодня в %s",
+		msgTimeTomorrow:     "завтра в %s",
+		msgTimeInDays:       "через %d дн. в %s",
+		msgTimeOnDate:       "%s в %s",
+	},
+	"pt": {
+		msgBotDescription: makeSingleLine(`
+Este bot permite criar lembretes. Use /set para indicar um horário e uma mensagem, e ele vai
+enviar a mensagem neste chat nesse horário.
+`),
+		msgStart:            "Olá! %s\nUse /help para mais detalhes.",
+		msgHelpIntro:        "%s Estes são os comandos disponíveis:\n\n%s",
+		msgReminderSet:      "@%s, vou lembrar você de __%s__ %s.",
+		msgSetUsage:         "@%s, não entendi. Tente algo como /set amanhã às 16h lavar a louça.",
+		msgSetAmbiguous:     "@%s, não sei qual parte disso é o horário. Coloque um = entre o horário e a descrição, como em /set amanhã às 16h = lavar a louça.",
+		msgSetBadTime:       "@%s, não consegui entender o horário '%s'.",
+		msgSetConfirm:       "@%s, você quis dizer %s? Vou lembrar você de __%s__ nesse horário.",
+		msgSetInPast:        "@%s, %s já passou, então não posso lembrar você nesse horário.",
+		msgSetExpired:       "Esse lembrete expirou, por favor crie-o de novo com /set.",
+		msgSetNotYours:      "Só quem criou o lembrete pode escolher o horário.",
+		msgSetCancelled:     "@%s, certo, não vou lembrar você de __%s__.",
+		msgSetAskWhat:       "@%s, do que devo lembrar você?",
+		msgSetAskWhen:       "@%s, quando devo lembrar você de __%s__? Digite um horário ou escolha um abaixo.",
+		msgSetOrPick:        "Ou escolha um horário:",
+		msgPickHour:         "Daqui a uma hora",
+		msgPickTonight:      "Hoje às 20h",
+		msgPickTomorrow:     "Amanhã às 9h",
+		msgPickWeek:         "Daqui a uma semana",
+		msgButtonConfirm:    "Confirmar",
+		msgButtonCancel:     "Cancelar",
+		msgLimitTooMany:     "@%s, você já tem %d lembretes pendentes, que é o máximo que posso guardar. Use /del para remover alguns antes.",
+		msgLimitTooFar:      "@%s, só posso criar lembretes com até %d dias de antecedência.",
+		msgLimitTooLong:     "@%s, esse lembrete é longo demais: com a descrição ele ocupa mais de %d bytes. Por favor, encurte a descrição.",
+		msgLimitDescTooLong: "@%s, essa descrição é longa demais: ela pode ter no máximo %d caracteres.",
+		msgListEmpty:        "@%s, você ainda não tem lembretes (hora de criar alguns).",
+		msgListHeader:       "@%s, estes são os seus lembretes:\n%s",
+		msgListPage:         "Página %d de %d",
+		msgListTagHeader:    "@%s, estes são os seus lembretes com a tag #%s:\n%s",
+		msgListTagEmpty:     "@%s, você não tem lembretes com a tag #%s.",
+		msgListUsage:        "@%s, não entendi. Use /list para ver todos os seus lembretes, ou /list #tag para ver os que têm uma tag.",
+		msgListNotYours:     "Estes botões são de quem enviou /list. Envie /list para ver os seus lembretes.",
+		msgListMoved:        "Lembrete %d movido para %s.",
+		msgListAskEdit:      "@%s, quando devo lembrar você de __%s__ em vez disso?",
+		msgAgendaHeader:     "@%s, isto é o que você tem %s:\n\n%s",
+		msgAgendaEmpty:      "@%s, você não tem nada %s.",
+		msgAgendaToday:      "para hoje",
+		msgAgendaTomorrow:   "para amanhã",
+		msgAgendaWeek:       "nos próximos 7 dias",
+		msgAgendaMore:       "…e mais %d, use /list para ver todos.",
+		msgDigest:           "@%s, este é o seu dia:\n\n%s",
+		msgDigestEmpty:      "@%s, você não tem lembretes hoje.",
+		msgDigestCurrent:    "@%s, envio um resumo do seu dia às %s. Use /digest off para parar.",
+		msgDigestNone:       "@%s, você não recebe um resumo diário. Use /digest com um horário, como /digest 7:30, para ativar.",
+		msgDigestSet:        "@%s, pronto, vou enviar um resumo do seu dia aqui todos os dias às %s.",
+		msgDigestOff:        "@%s, certo, sem mais resumos diários.",
+		msgQuietNone:        "@%s, você não tem horário de silêncio. Defina um com algo como /quiet 22:00-07:00.",
+		msgQuietHeld:        "@%s, seu horário de silêncio é das %s às %s. Lembretes desse horário serão enviados quando ele terminar.",
+		msgQuietSilent:      "@%s, seu horário de silêncio é das %s às %s. Lembretes desse horário serão enviados sem notificação.",
+		msgQuietOff:         "@%s, certo, horário de silêncio removido.",
+		msgQuietUsage:       "@%s, não entendi. Tente algo como /quiet 22:00-07:00, ou /quiet 22:00-07:00 silent.",
+		msgFindUsage:        "@%s, o que devo procurar? Tente algo como /find dentista.",
+		msgFindNone:         "@%s, não encontrei lembretes com '%s'.",
+		msgFindResults:      "@%s, isto é o que encontrei para '%s':\n%s",
+		msgFindMore:         "Só os primeiros %d são mostrados, tente mais palavras para refinar a busca.",
+		msgFindPast:         "Já disparados:\n%s",
+		msgHistoryEmpty:     "@%s, nenhum dos seus lembretes disparou recentemente.",
+		msgHistoryHeader:    "@%s, seus lembretes disparados recentemente:\n%s",
+		msgHistoryDelivered: "entregue",
+		msgHistoryFailed:    "não entregue",
+		msgHistoryDone:      "feito ✓",
+		msgHistoryAcked:     "Marcado como feito ✓",
+		msgHistoryCantAck:   "Este lembrete não pode ser marcado como feito, é de outra pessoa ou é antigo demais.",
+		msgButtonDone:       "Feito ✓",
+		msgButtonUndo:       "Desfazer",
+		msgUndoDone:         "@%s, trouxe de volta %d lembretes.",
+		msgUndoNothing:      "@%s, não há nada para desfazer.",
+		msgUndoExpired:      "Isso não pode mais ser desfeito.",
+		msgUndoHint:         "Use /undo para trazê-lo de volta.",
+		msgDelUsage:         "@%s, não entendi. Tente /del 3, /del 3 5 7, /del 3-9 ou /del #tag.",
+		msgDelManyDone:      "@%s, apaguei os lembretes %s.",
+		msgDelManyMissing:   "Não encontrei %s.",
+		msgDelManyNone:      "@%s, não encontrei nenhum desses lembretes para apagar...",
+		msgClearNone:        "@%s, você não tem lembretes neste chat.",
+		msgClearConfirm:     "@%s, tem certeza de que quer apagar todos os seus %d lembretes neste chat?",
+		msgClearDone:        "@%s, apaguei %d lembretes.",
+		msgClearCancelled:   "@%s, ok, não vou apagar nada.",
+		msgClearNotYours:    "Só quem enviou /clear pode confirmar.",
+		msgClearExpired:     "@%s, essa confirmação expirou. Envie /clear de novo.",
+		msgButtonClear:      "Apagar %d",
+		msgButtonDelete:     "Apagar %d",
+		msgButtonEdit:       "Editar %d",
+		msgButtonSnooze:     "Adiar %d",
+		msgButtonPrev:       "« Anterior",
+		msgButtonNext:       "Próxima »",
+		msgButtonBack:       "Voltar",
+		msgSnoozeHour:       "+1 hora",
+		msgSnoozeDay:        "+1 dia",
+		msgSnoozeWeek:       "+1 semana",
+		msgDelNotFound:      "@%s, não encontrei nenhum lembrete com ID %d para apagar...",
+		msgDelDone:          "@%s, apaguei o lembrete com ID %d. (:",
+		msgDelTagDone:       "@%s, apaguei %d lembretes com a tag #%s.",
+		msgReminderFired:    "@%s, você pediu para eu lembrar você disto neste horário:\n%s",
+		msgAccessRejected:   "Desculpe, ainda não estou aceitando lembretes seus. Se você deveria ter acesso, peça a um administrador para usar /allow - seu ID de usuário é %d.",
+		msgAccessAdminOnly:  "Desculpe, só administradores podem mudar quem pode usar este bot.",
+		msgAccessAllowed:    "Pronto, %d agora pode usar este bot.",
+		msgAccessDenied:     "Pronto, %d não pode mais usar este bot.",
+		msgLangCurrent:      "Seu idioma é %s. Idiomas disponíveis: %s. Use /lang <código> para mudar, ou /lang auto para seguir as configurações do Telegram.",
+		msgLangSet:          "Pronto, a partir de agora vou usar o idioma %s.",
+		msgLangAuto:         "Pronto, vou seguir as configurações de idioma do Telegram.",
+		msgLangUnknown:      "Desculpe, ainda não falo '%s'. Idiomas disponíveis: %s.",
+		msgTimeToday:        "hoje às %s",
+		msgTimeTomorrow:     "amanhã às %s",
+		msgTimeInDays:       "daqui a %d dias às %s",
+		msgTimeOnDate:       "em %s às %s",
+	},
+}
+
+// clock24Langs are the languages that display times on a 24-hour clock.
+var clock24Langs = map[string]bool{"ru": true, "pt": true}
+
+type dateNames struct {
+	weekdays [7]string
+	months   [12]string
+}
+
+// calendars holds abbreviated day and month names, starting from Sunday and
+// January.
+var calendars = map[string]dateNames{
+	"en": {
+		weekdays: [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+		months:   [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+	},
+	"ru": {
+		weekdays: [7]string{"вс", "пн", "вт", "ср", "чт", "пт", "сб"},
+		months:   [12]string{"янв", "фев", "мар", "апр", "мая", "июн", "июл", "авг", "сен", "окт", "ноя", "дек"},
+	},
+	"pt": {
+		weekdays: [7]string{"dom", "seg", "ter", "qua", "qui", "sex", "sáb"},
+		months:   [12]string{"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+	},
+}
+
+// tr returns the message for key in lang, formatted with args. Messages
+// missing from lang's catalog fall back to the default language.
+// tr formats the message key in lang. String args are escaped with
+// escapeValue, as they may come from users; args that are already markdown
+// are inserted as they are.
+func tr(lang string, key msgKey, args ...any) string {
+
+	f, ok := catalogs[lang][key]
+	if !ok {
+		f = catalogs[defaultLang][key]
+	}
+	if len(args) == 0 {
+		return f
+	}
+	escaped := make([]any, len(args))
+	for i, a := range args {
+		switch v := a.(type) {
+		case string:
+			escaped[i] = escapeValue(v)
+		case markdown:
+			escaped[i] = string(v)
+		default:
+			escaped[i] = a
+		}
+	}
+	return fmt.Sprintf(f, escaped...)
+}
+
+func supportedLangs() []string {
+
+	ret := make([]string, 0, len(catalogs))
+	for k := range catalogs {
+		ret = append(ret, k)
+	}
+	slices.Sort(ret)
+	return ret
+}
+
+// normalizeLang maps an IETF language tag like "pt-br" to a supported
+// language, or "" if there isn't one.
+func normalizeLang(code string) string {
+
+	code = strings.ToLower(code)
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	if _, ok := catalogs[code]; ok {
+		return code
+	}
+	return ""
+}
+
+// userLang returns the language to talk to the sender of an update in: the
+// one they chose with /lang, else their Telegram language, else the default.
+func userLang(l *later.Later, ctx *gobot.Context) string {
+
+	if ctx.EffectiveSender == nil || ctx.EffectiveSender.User == nil {
+		return defaultLang
+	}
+	u := ctx.EffectiveSender.User
+	lang, found, err := l.GetSetting(u.Username, langSettingKey)
+	if err != nil {
+		log.Err(err).Str("username", u.Username).Msg("while getting language setting")
+	}
+	if found {
+		if lang = normalizeLang(lang); lang != "" {
+			return lang
+		}
+	}
+	if lang = normalizeLang(u.LanguageCode); lang != "" {
+		return lang
+	}
+	return defaultLang
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGetTimeDisplayString_Localized(t *testing.T) {
+
+	const day = time.Hour * 24
+	now := time.Unix(0, 0).UTC()
+	tcs := []struct {
+		name     string
+		lang     string
+		ref      time.Time
+		expected string
+	}{
+		{
+			name:     "ru today",
+			lang:     "ru",
+			ref:      now.Add(13*time.Hour + 5*time.Minute),
+			expected: "сегодня в 13:05",
+		},
+		{
+			name:     "ru tomorrow with secs",
+			lang:     "ru",
+			ref:      now.Add(day + time.Second),
+			expected: "завтра в 00:00:01",
+		},
+		{
+			name:     "ru days",
+			lang:     "ru",
+			ref:      now.Add(3*day + 9*time.Hour),
+			expected: "через 3 дн. в 09:00",
+		},
+		{
+			name:     "pt today",
+			lang:     "pt",
+			ref:      now.Add(21 * time.Hour),
+			expected: "hoje às 21:00",
+		},
+		{
+			name:     "pt on date",
+			lang:     "pt",
+			ref:      now.Add(8*day + 7*time.Hour + 30*time.Minute),
+			expected: "em 1970-01-09 às 07:30",
+		},
+		{
+			name:     "unknown language falls back",
+			lang:     "xx",
+			ref:      now.Add(time.Hour),
+			expected: "today at 1AM",
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			res := getTimeDisplayString(tc.lang, now, tc.ref)
+			if res != tc.expected {
+				t.Errorf("Comparison failed, expected '%s', got '%s'", tc.expected, res)
+			}
+		})
+	}
+}
+
+func TestCatalogsComplete(t *testing.T) {
+
+	for lang, catalog := range catalogs {
+		for key := range catalogs[defaultLang] {
+			if _, ok := catalog[key]; !ok {
+				t.Errorf("%s catalog is missing %s", lang, key)
+			}
+		}
+	}
+}
+
+func TestNormalizeLang(t *testing.T) {
+
+	tcs := map[string]string{
+		"en":    "en",
+		"pt-BR": "pt",
+		"ru_RU": "ru",
+		"de":    "",
+		"":      "",
+	}
+	for in, expected := range tcs {
+		if res := normalizeLang(in); res != expected {
+			t.Errorf("normalizeLang(%q): expected %q, got %q", in, expected, res)
+		}
+	}
+}
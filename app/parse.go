@@ -0,0 +1,229 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"github.com/olebedev/when"
+	"github.com/olebedev/when/rules"
+	"github.com/olebedev/when/rules/br"
+	"github.com/olebedev/when/rules/common"
+	"github.com/olebedev/when/rules/en"
+	"github.com/olebedev/when/rules/ru"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parsers holds a time string parser for each supported language.
+type Parsers map[string]*when.Parser
+
+func newParser(ruleSets ...[]rules.Rule) *when.Parser {
+	w := when.New(nil)
+	for _, rs := range ruleSets {
+		w.Add(rs...)
+	}
+	return w
+}
+
+// NewParsers returns parsers for every language in the message catalogs.
+// English phrases are understood in every language, since they're what the
+// help text uses in its examples. Rules are applied in the order they're
+// added, so later rules win where they overlap.
+func NewParsers() Parsers {
+	return Parsers{
+		"en": newParser(en.All, common.All),
+		"ru": newParser(ruExtra, ru.All, en.All, common.All),
+		"pt": newParser(ptExtra, br.All, en.All, common.All),
+	}
+}
+
+// For returns the parser for lang, or the default language's parser if lang
+// has none.
+func (p Parsers) For(lang string) *when.Parser {
+	if w, ok := p[lang]; ok {
+		return w
+	}
+	return p[defaultLang]
+}
+
+// some cases that 'when' doesn't get
+var specialCases = []string{time.DateOnly, time.RFC3339, "2006-01-02T15:04:05"}
+
+func parseSpecialCase(s string) (time.Time, bool) {
+	for _, layout := range specialCases {
+		specialCase, err := time.ParseInLocation(layout, s, tz())
+		if err == nil {
+			return specialCase, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (p Parsers) parseTimeString(lang, s string, now time.Time) (time.Time, []time.Time, error) {
+
+	if specialCase, ok := parseSpecialCase(s); ok {
+		return specialCase, nil, nil
+	}
+
+	parse, err := p.For(lang).Parse(s, now.Truncate(time.Second))
+	if err != nil {
+		return time.Time{}, nil, err
+	}
+	if parse == nil {
+		return time.Time{}, nil, errors.New("no match found for text")
+	}
+
+	return parse.Time, alternativeTimes(s, parse.Time, now), nil
+}
+
+// ruExtra and ptExtra cover common phrases that when's own rules for these
+// languages don't: a bare 24-hour clock time like "в 9" or "às 9h", and the
+// day after tomorrow.
+var ruExtra = []rules.Rule{
+	clockHour(`в|к`, `\s*час(?:а|ов)?`),
+	dayOffset(`послезавтра`, 2),
+}
+
+var ptExtra = []rules.Rule{
+	clockHour(`às|as|à`, `h(\d{2})?|\s*horas?`),
+	// when's br rules already count one day for the "amanhã".
+	dayOffset(`depois\s+de\s+amanhã`, 1),
+	ptDeadline,
+}
+
+// clockHour matches a preposition followed by an hour on the 24-hour clock
+// and an optional suffix, which may capture minutes.
+func clockHour(prepositions, suffix string) rules.Rule {
+	return &rules.F{
+		RegExp: regexp.MustCompile(`(?i)(?:\P{L}|^)(` + prepositions + `)\s+(\d{1,2})(` + suffix + `)?(?:[^\p{L}\d:]|$)`),
+		Applier: func(m *rules.Match, c *rules.Context, o *rules.Options, ref time.Time) (bool, error) {
+			hour, err := strconv.Atoi(m.Captures[1])
+			if err != nil || hour > 23 {
+				return false, nil
+			}
+			minute := 0
+			if len(m.Captures) > 3 && m.Captures[3] != "" {
+				minute, err = strconv.Atoi(m.Captures[3])
+				if err != nil || minute > 59 {
+					return false, nil
+				}
+			}
+			c.Hour = &hour
+			c.Minute = &minute
+			return true, nil
+		},
+	}
+}
+
+// dayOffset matches a phrase that moves the date forward by days.
+func dayOffset(phrase string, days int) rules.Rule {
+	return &rules.F{
+		RegExp: regexp.MustCompile(`(?i)(?:\P{L}|^)(` + phrase + `)(?:\P{L}|$)`),
+		Applier: func(m *rules.Match, c *rules.Context, o *rules.Options, ref time.Time) (bool, error) {
+			c.Duration += time.Duration(days) * 24 * time.Hour
+			return true, nil
+		},
+	}
+}
+
+var ptUnits = map[string]time.Duration{
+	"minuto": time.Minute,
+	"hora":   time.Hour,
+	"dia":    24 * time.Hour,
+	"semana": 7 * 24 * time.Hour,
+}
+
+// ptDeadline matches "daqui a 2 horas", which when's br rules only know as
+// "em 2 horas".
+var ptDeadline = &rules.F{
+	RegExp: regexp.MustCompile(`(?i)(?:\P{L}|^)(daqui\s+a)\s+(\d+|` + br.INTEGER_WORDS_PATTERN + `)\s+(minutos?|horas?|dias?|semanas?)(?:\P{L}|$)`),
+	Applier: func(m *rules.Match, c *rules.Context, o *rules.Options, ref time.Time) (bool, error) {
+		n, ok := br.INTEGER_WORDS[strings.ToLower(m.Captures[1])]
+		if !ok {
+			var err error
+			if n, err = strconv.Atoi(m.Captures[1]); err != nil {
+				return false, nil
+			}
+		}
+		unit := ptUnits[strings.TrimSuffix(strings.ToLower(m.Captures[2]), "s")]
+		c.Duration += time.Duration(n) * unit
+		return true, nil
+	},
+}
+
+// errAmbiguousTime is returned when the time in a reminder's text can't be
+// told apart from its description.
+var errAmbiguousTime = fmt.Errorf("couldn't separate time from description: %w", ErrInvalidCmd)
+
+// connectives are words that join a time to a description, like the "at" in
+// "call mum at 5pm", which when leaves out of the time it matches.
+var connectives = map[string]bool{
+	"at": true, "on": true, "by": true, "in": true,
+	"в": true, "во": true, "к": true, "на": true,
+	"às": true, "as": true, "à": true, "em": true, "no": true, "na": true,
+}
+
+func trimConnective(s string, fromEnd bool) string {
+
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+	if fromEnd && connectives[strings.ToLower(fields[len(fields)-1])] {
+		fields = fields[:len(fields)-1]
+	} else if !fromEnd && connectives[strings.ToLower(fields[0])] {
+		fields = fields[1:]
+	}
+	return strings.Join(fields, " ")
+}
+
+// splitReminderText separates the text of a reminder into the time and the
+// description, using where w finds the time. The time has to be at the start
+// or the end of the text, otherwise it's ambiguous which part the
+// description is.
+func splitReminderText(w *when.Parser, s string, now time.Time) (string, string, error) {
+
+	s = strings.TrimSpace(s)
+	if first, rest, ok := strings.Cut(s, " "); ok {
+		if _, ok = parseSpecialCase(first); ok {
+			return first, strings.TrimSpace(rest), nil
+		}
+	}
+
+	res, err := w.Parse(s, now)
+	if err != nil {
+		return "", "", err
+	}
+	if res == nil {
+		return "", "", fmt.Errorf("no time found: %w", ErrInvalidCmd)
+	}
+	before := strings.TrimSpace(s[:res.Index])
+	after := strings.TrimSpace(s[res.Index+len(res.Text):])
+	timeString := strings.TrimSpace(res.Text)
+	switch {
+	case before != "" && after != "":
+		return "", "", errAmbiguousTime
+	case before != "":
+		return timeString, trimConnective(before, true), nil
+	default:
+		return timeString, trimConnective(after, false), nil
+	}
+}
+
+// splitSetText separates the text of a /set into the time and the
+// description. It finds the time the way splitReminderText does, so an "="
+// in the description doesn't split it, and only splits on "=" when that
+// finds no time, finds an ambiguous one or finds one spanning the "=".
+func splitSetText(w *when.Parser, s string, now time.Time) (string, string, error) {
+
+	timeString, name, err := splitReminderText(w, s, now)
+	if err == nil && !strings.Contains(timeString, "=") {
+		// The time may have come before or after an explicit "=".
+		return timeString, strings.Trim(name, "= "), nil
+	}
+	if before, after, ok := strings.Cut(s, "="); ok {
+		return strings.TrimSpace(before), strings.TrimSpace(after), nil
+	}
+	return "", "", err
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParsers(t *testing.T) {
+
+	// A Wednesday.
+	base := time.Date(2025, 1, 8, 12, 0, 0, 0, time.UTC)
+	at := func(day, hour, min int) time.Time {
+		return time.Date(2025, 1, day, hour, min, 0, 0, time.UTC)
+	}
+	tcs := []struct {
+		lang     string
+		text     string
+		expected time.Time
+	}{
+		{"en", "tomorrow 4pm", at(9, 16, 0)},
+		{"en", "in 2 hours", at(8, 14, 0)},
+		{"ru", "завтра в 9", at(9, 9, 0)},
+		{"ru", "завтра в 21 час", at(9, 21, 0)},
+		{"ru", "послезавтра в 10", at(10, 10, 0)},
+		{"ru", "через 2 часа", at(8, 14, 0)},
+		{"ru", "в пятницу в 10:30", at(10, 10, 30)},
+		{"ru", "завтра в 7 вечера", at(9, 19, 0)},
+		{"ru", "tomorrow 4pm", at(9, 16, 0)},
+		{"pt", "amanhã às 9", at(9, 9, 0)},
+		{"pt", "amanhã às 9h30", at(9, 9, 30)},
+		{"pt", "depois de amanhã às 18h", at(10, 18, 0)},
+		{"pt", "daqui a 2 horas", at(8, 14, 0)},
+		{"pt", "em 3 dias", at(11, 12, 0)},
+		{"pt", "sexta-feira às 10:30", at(10, 10, 30)},
+		{"pt", "tomorrow 4pm", at(9, 16, 0)},
+		{"de", "tomorrow 4pm", at(9, 16, 0)},
+	}
+	ps := NewParsers()
+	for _, tc := range tcs {
+		t.Run(tc.lang+" "+tc.text, func(t *testing.T) {
+			res, err := ps.For(tc.lang).Parse(tc.text, base)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res == nil {
+				t.Fatalf("no match for '%s'", tc.text)
+			}
+			if !res.Time.Equal(tc.expected) {
+				t.Errorf("Comparison failed, expected '%s', got '%s'", tc.expected, res.Time)
+			}
+		})
+	}
+}
+
+func TestSplitReminderText(t *testing.T) {
+
+	now := time.Date(2025, 1, 8, 12, 0, 0, 0, time.UTC)
+	tcs := []struct {
+		lang         string
+		text         string
+		expectedTime string
+		expectedName string
+		expectedErr  error
+	}{
+		{"en", "tomorrow 4pm do the dishes", "tomorrow 4pm", "do the dishes", nil},
+		{"en", "do the dishes in 2 hours", "in 2 hours", "do the dishes", nil},
+		{"en", "call mum at 5pm", "5pm", "call mum", nil},
+		{"en", "call mum on friday", "friday", "call mum", nil},
+		{"en", "next monday renew passport", "next monday", "renew passport", nil},
+		{"en", "in 10 minutes take the bread out", "in 10 minutes", "take the bread out", nil},
+		{"en", "2025-01-11 pay rent", "2025-01-11", "pay rent", nil},
+		{"en", "2025-01-11T11:39:00 pay rent", "2025-01-11T11:39:00", "pay rent", nil},
+		{"en", "call mum tomorrow about the party", "", "", errAmbiguousTime},
+		{"en", "do the dishes", "", "", ErrInvalidCmd},
+		{"ru", "завтра в 9 позвонить маме", "завтра в 9", "позвонить маме", nil},
+		{"ru", "позвонить маме через 2 часа", "через 2 часа", "позвонить маме", nil},
+		{"pt", "amanhã às 9 ligar para a mãe", "amanhã às 9", "ligar para a mãe", nil},
+		{"pt", "ligar para a mãe daqui a 2 horas", "daqui a 2 horas", "ligar para a mãe", nil},
+	}
+	ps := NewParsers()
+	for _, tc := range tcs {
+		t.Run(tc.lang+" "+tc.text, func(t *testing.T) {
+			timeString, name, err := splitReminderText(ps.For(tc.lang), tc.text, now)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if timeString != tc.expectedTime || name != tc.expectedName {
+				t.Errorf("Comparison failed, expected '%s' / '%s', got '%s' / '%s'", tc.expectedTime, tc.expectedName, timeString, name)
+			}
+		})
+	}
+}
+
+func TestSplitSetText(t *testing.T) {
+
+	now := time.Date(2025, 1, 8, 12, 0, 0, 0, time.UTC)
+	tcs := []struct {
+		text         string
+		expectedTime string
+		expectedName string
+		expectedErr  error
+	}{
+		{"tomorrow 4:00pm = do the dishes", "tomorrow 4:00pm", "do the dishes", nil},
+		{"tomorrow 4:00pm do the dishes", "tomorrow 4:00pm", "do the dishes", nil},
+		{"in 2 hours check x=5", "in 2 hours", "check x=5", nil},
+		{"check x=5 in 2 hours", "in 2 hours", "check x=5", nil},
+		{"2025-01-11 = pay rent", "2025-01-11", "pay rent", nil},
+		{"call mum tomorrow about the party", "", "", errAmbiguousTime},
+		{"the day after payday = pay rent", "the day after payday", "pay rent", nil},
+		{"next friday = meeting at 3", "next friday", "meeting at 3", nil},
+		{"call mom = tomorrow at 5pm", "tomorrow at 5pm", "call mom", nil},
+	}
+	ps := NewParsers()
+	for _, tc := range tcs {
+		t.Run(tc.text, func(t *testing.T) {
+			timeString, name, err := splitSetText(ps.For("en"), tc.text, now)
+			if !errors.Is(err, tc.expectedErr) {
+				t.Fatalf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if timeString != tc.expectedTime || name != tc.expectedName {
+				t.Errorf("Comparison failed, expected '%s' / '%s', got '%s' / '%s'", tc.expectedTime, tc.expectedName, timeString, name)
+			}
+		})
+	}
+}
+
+func TestQuickPicks(t *testing.T) {
+
+	w := NewParsers().For(defaultLang)
+	now := time.Date(2025, 1, 8, 12, 0, 0, 0, time.UTC)
+	for _, p := range quickPicks {
+		res, err := w.Parse(p.phrase, now)
+		if err != nil || res == nil || !res.Time.After(now) {
+			t.Errorf("quick pick '%s' didn't parse to a future time: %v, %v", p.phrase, res, err)
+		}
+	}
+}
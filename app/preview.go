@@ -0,0 +1,125 @@
+package app
+
+import (
+	"github.com/henges/later/later"
+	"github.com/olebedev/when/rules"
+	"github.com/olebedev/when/rules/br"
+	"github.com/olebedev/when/rules/en"
+	"github.com/olebedev/when/rules/ru"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// pendingTTL is how long a previewed reminder waits to be confirmed.
+const pendingTTL = 15 * time.Minute
+
+// pendingReminder is a reminder that's been previewed but not yet confirmed.
+// times holds the parsed fire time followed by its alternatives.
+type pendingReminder struct {
+	userID   int64
+	lang     string
+	reminder later.Reminder
+	cbd      TelegramCallbackData
+	times    []time.Time
+	expires  time.Time
+}
+
+type pendingStore struct {
+	mu    sync.Mutex
+	items map[string]pendingReminder
+}
+
+func newPendingStore() *pendingStore {
+	return &pendingStore{items: make(map[string]pendingReminder)}
+}
+
+// add stores p and returns the token it can be taken with.
+func (s *pendingStore) add(p pendingReminder, now time.Time) string {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range s.items {
+		if now.After(v.expires) {
+			delete(s.items, k)
+		}
+	}
+	token := newToken()
+	p.expires = now.Add(pendingTTL)
+	s.items[token] = p
+	return token
+}
+
+// get returns the reminder stored under token, if it hasn't expired.
+func (s *pendingStore) get(token string, now time.Time) (pendingReminder, bool) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.items[token]
+	if !ok || now.After(p.expires) {
+		return pendingReminder{}, false
+	}
+	return p, true
+}
+
+func (s *pendingStore) remove(token string) {
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, token)
+}
+
+// meridiemPattern matches an explicit morning or evening marker, in any of
+// the supported languages.
+var meridiemPattern = regexp.MustCompile(`(?i)\d\s*(?:a|p)\.?m?\.?(?:\P{L}|$)|утра|вечера|дня|ночи|manhã|tarde|noite`)
+
+var digitPattern = regexp.MustCompile(`\d`)
+
+var weekdayRules = []rules.Rule{
+	en.Weekday(rules.Override),
+	ru.Weekday(rules.Override),
+	br.Weekday(rules.Override),
+}
+
+// deadlineRules match times given relative to now, like "in 2 hours".
+var deadlineRules = []rules.Rule{
+	en.Deadline(rules.Override),
+	ru.Deadline(rules.Override),
+	br.Deadline(rules.Override),
+	ptDeadline,
+}
+
+func matchesAny(rs []rules.Rule, s string) bool {
+	for _, r := range rs {
+		if r.Find(s) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// alternativeTimes returns other plausible readings of the time string s,
+// which was parsed as t: the other half of the day if s didn't say which,
+// and the following week if s named a day of the week. Times relative to now
+// aren't ambiguous. Readings that are already in the past are left out.
+func alternativeTimes(s string, t, now time.Time) []time.Time {
+
+	if matchesAny(deadlineRules, s) {
+		return nil
+	}
+	var ret []time.Time
+	h := t.Hour()
+	if h >= 1 && h <= 11 && digitPattern.MatchString(s) && !meridiemPattern.MatchString(s) {
+		ret = append(ret, t.Add(12*time.Hour))
+	}
+	if matchesAny(weekdayRules, s) {
+		ret = append(ret, t.AddDate(0, 0, 7))
+	}
+	filtered := ret[:0]
+	for _, alt := range ret {
+		if alt.After(now) {
+			filtered = append(filtered, alt)
+		}
+	}
+	return filtered
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAlternativeTimes(t *testing.T) {
+
+	// A Wednesday.
+	now := time.Date(2025, 1, 8, 12, 0, 0, 0, time.UTC)
+	at := func(day, hour int) time.Time {
+		return time.Date(2025, 1, day, hour, 0, 0, 0, time.UTC)
+	}
+	tcs := []struct {
+		name     string
+		text     string
+		parsed   time.Time
+		expected []time.Time
+	}{
+		{
+			name:     "hour without meridiem",
+			text:     "tomorrow at 5:00",
+			parsed:   at(9, 5),
+			expected: []time.Time{at(9, 17)},
+		},
+		{
+			name:   "explicit am",
+			text:   "tomorrow 5am",
+			parsed: at(9, 5),
+		},
+		{
+			name:   "explicit russian morning",
+			text:   "завтра в 5 утра",
+			parsed: at(9, 5),
+		},
+		{
+			name:   "afternoon",
+			text:   "tomorrow 17:00",
+			parsed: at(9, 17),
+		},
+		{
+			name:   "relative",
+			text:   "in 2 hours",
+			parsed: at(8, 2),
+		},
+		{
+			name:   "no hour given",
+			text:   "tomorrow",
+			parsed: at(9, 10),
+		},
+		{
+			name:     "weekday",
+			text:     "friday 5pm",
+			parsed:   at(10, 17),
+			expected: []time.Time{at(17, 17)},
+		},
+		{
+			name:     "portuguese weekday without meridiem",
+			text:     "sexta às 9",
+			parsed:   at(10, 9),
+			expected: []time.Time{at(10, 21), at(17, 9)},
+		},
+		{
+			name:     "morning already passed",
+			text:     "today at 1:00",
+			parsed:   at(8, 1),
+			expected: []time.Time{at(8, 13)},
+		},
+		{
+			name:     "weekday already passed",
+			text:     "monday at 9pm",
+			parsed:   at(6, 21),
+			expected: []time.Time{at(13, 21)},
+		},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			res := alternativeTimes(tc.text, tc.parsed, now)
+			if !slices.EqualFunc(res, tc.expected, time.Time.Equal) {
+				t.Errorf("Comparison failed, expected '%v', got '%v'", tc.expected, res)
+			}
+		})
+	}
+}
+
+func TestPendingStore(t *testing.T) {
+
+	now := time.Now()
+	s := newPendingStore()
+	token := s.add(pendingReminder{userID: 1}, now)
+
+	p, ok := s.get(token, now.Add(time.Minute))
+	if !ok || p.userID != 1 {
+		t.Fatalf("expected pending reminder for user 1, got %v, %v", p, ok)
+	}
+	if _, ok = s.get(token, now.Add(pendingTTL+time.Second)); ok {
+		t.Error("expected pending reminder to have expired")
+	}
+	s.remove(token)
+	if _, ok = s.get(token, now); ok {
+		t.Error("expected pending reminder to have been removed")
+	}
+	// A store made after a restart doesn't reuse the old store's tokens.
+	if other := newPendingStore().add(pendingReminder{userID: 2}, now); other == token {
+		t.Errorf("expected a new store to give a different token, got %s twice", token)
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+
+	d := time.Date(2024, 10, 18, 17, 0, 0, 0, time.UTC)
+	for lang, expected := range map[string]string{
+		"en": "Fri 18 Oct 5PM",
+		"ru": "пт 18 окт 17:00",
+		"pt": "sex 18 out 17:00",
+	} {
+		if res := formatDate(lang, d); res != expected {
+			t.Errorf("for %s, expected '%s', got '%s'", lang, expected, res)
+		}
+	}
+}
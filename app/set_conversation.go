@@ -0,0 +1,145 @@
+package app
+
+import (
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/henges/later/bot"
+	"strconv"
+	"time"
+)
+
+// setConversation is the state of a /set started without any arguments. The
+// description is asked for first, then the time.
+type setConversation struct {
+	name string
+}
+
+// quickPicks are offered as answers when asking for a reminder's time. The
+// phrases are understood in every language.
+var quickPicks = []struct {
+	label  msgKey
+	phrase string
+}{
+	{msgPickHour, "in 1 hour"},
+	{msgPickTonight, "today 8pm"},
+	{msgPickTomorrow, "tomorrow 9am"},
+	{msgPickWeek, "in 1 week"},
+}
+
+func (h *SetReminder) askWhat(b *gotgbot.Bot, ctx *gobot.Context, lang string) error {
+	user := ctx.EffectiveSender.User.Username
+
+	bot.StartConversation(ctx, setConversation{})
+	_, err := b.SendMessage(ctx.EffectiveChat.Id, escapeMarkdownV2(tr(lang, msgSetAskWhat, user)), &gotgbot.SendMessageOpts{
+		ParseMode: "MarkdownV2",
+		// Without this, Telegram won't send us the answer in groups.
+		ReplyMarkup: gotgbot.ForceReply{ForceReply: true, Selective: true},
+	})
+	if err != nil {
+		countTelegramError(err)
+	}
+	return err
+}
+
+func (h *SetReminder) askWhen(b *gotgbot.Bot, ctx *gobot.Context, lang string, conv setConversation, now time.Time) error {
+	user := ctx.EffectiveSender.User.Username
+
+	bot.StartConversation(ctx, conv)
+	// A typed answer needs ForceReply in groups too, and a message can't have
+	// that and buttons, so the quick picks follow in a message of their own.
+	_, err := b.SendMessage(ctx.EffectiveChat.Id, escapeMarkdownV2(tr(lang, msgSetAskWhen, user, conv.name)), &gotgbot.SendMessageOpts{
+		ParseMode:   "MarkdownV2",
+		ReplyMarkup: gotgbot.ForceReply{ForceReply: true, Selective: true},
+	})
+	if err != nil {
+		countTelegramError(err)
+		return err
+	}
+	uid := strconv.FormatInt(ctx.EffectiveSender.Id(), 10)
+	var keyboard [][]gotgbot.InlineKeyboardButton
+	for i, p := range quickPicks {
+		if t, _, err := h.p.parseTimeString(defaultLang, p.phrase, now); err != nil || !t.After(now) {
+			continue
+		}
+		keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{
+			{Text: tr(lang, p.label), CallbackData: bot.CallbackData("set", quickPickArg, uid, strconv.Itoa(i))},
+		})
+	}
+	keyboard = append(keyboard, []gotgbot.InlineKeyboardButton{
+		{Text: tr(lang, msgButtonCancel), CallbackData: bot.CallbackData("set", quickPickArg, uid, cancelChoice)},
+	})
+	return sendMessageWithKeyboard(b, ctx.EffectiveChat.Id, tr(lang, msgSetOrPick), keyboard)
+}
+
+// Reply handles the answers to the questions asked by askWhat and askWhen.
+func (h *SetReminder) Reply(b *gotgbot.Bot, ctx *gobot.Context) error {
+	lang := userLang(h.l, ctx)
+	now := time.Now().In(tz())
+
+	state, ok := bot.Conversation(ctx)
+	if !ok {
+		return nil
+	}
+	conv := state.(setConversation)
+	text := ctx.EffectiveMessage.Text
+	if conv.name == "" {
+		if err := h.l.CheckDescription(text); err != nil {
+			// Let them try again.
+			bot.StartConversation(ctx, conv)
+			return h.replyError(b, ctx, lang, err)
+		}
+		conv.name = text
+		return h.askWhen(b, ctx, lang, conv, now)
+	}
+	return h.answerWhen(b, ctx, lang, conv, text, now)
+}
+
+func (h *SetReminder) answerWhen(b *gotgbot.Bot, ctx *gobot.Context, lang string, conv setConversation, timeString string, now time.Time) error {
+
+	pr, err := h.newPending(ctx, lang, timeString, conv.name, now)
+	if err != nil {
+		// Let them try again.
+		bot.StartConversation(ctx, conv)
+		return h.replyError(b, ctx, lang, err)
+	}
+	bot.EndConversation(ctx)
+	return h.sendPreview(b, ctx, lang, pr, now)
+}
+
+func (h *SetReminder) quickPickCallback(b *gotgbot.Bot, ctx *gobot.Context, uid, choice string) error {
+	cq := ctx.CallbackQuery
+	lang := userLang(h.l, ctx)
+	now := time.Now().In(tz())
+
+	// Others in a group can press the buttons, but aren't having the
+	// conversation, so mustn't be told it expired.
+	if uid != strconv.FormatInt(cq.From.Id, 10) {
+		_, err := cq.Answer(b, &gotgbot.AnswerCallbackQueryOpts{Text: tr(lang, msgSetNotYours)})
+		return err
+	}
+	state, ok := bot.Conversation(ctx)
+	if !ok {
+		return answerExpired(b, cq, lang)
+	}
+	conv := state.(setConversation)
+	if choice == cancelChoice {
+		if _, err := cq.Answer(b, nil); err != nil {
+			return err
+		}
+		bot.EndConversation(ctx)
+		return editMessage(b, cq, tr(lang, msgSetCancelled, ctx.EffectiveSender.User.Username, conv.name), nil)
+	}
+	i, err := strconv.Atoi(choice)
+	if err != nil || i < 0 || i >= len(quickPicks) {
+		return fmt.Errorf("for callback %s, invalid choice: %w", cq.Data, ErrInvalidCmd)
+	}
+	if _, err = cq.Answer(b, nil); err != nil {
+		return err
+	}
+	err = editMessage(b, cq, tr(lang, msgSetOrPick)+" "+tr(lang, quickPicks[i].label), nil)
+	if err != nil {
+		return err
+	}
+	return h.answerWhen(b, ctx, lang, conv, quickPicks[i].phrase, now)
+}
@@ -0,0 +1,35 @@
+package app
+
+import (
+	"github.com/henges/later/later"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var tagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_])#([\p{L}\p{N}_]+)`)
+
+// extractTags returns the hashtags in a reminder's description, lowercased
+// and without the '#'. Hashtags longer than later.MaxTagBytes aren't tags.
+func extractTags(name string) []string {
+
+	var ret []string
+	for _, m := range tagPattern.FindAllStringSubmatch(name, -1) {
+		tag := strings.ToLower(m[1])
+		if len(tag) <= later.MaxTagBytes && !slices.Contains(ret, tag) {
+			ret = append(ret, tag)
+		}
+	}
+	return ret
+}
+
+// parseTag returns the tag s refers to, if s is a single hashtag like
+// "#work".
+func parseTag(s string) (string, bool) {
+
+	tags := extractTags(s)
+	if len(tags) != 1 || strings.ToLower(strings.TrimSpace(s)) != "#"+tags[0] {
+		return "", false
+	}
+	return tags[0], true
+}
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExtractTags(t *testing.T) {
+
+	tcs := []struct {
+		in       string
+		expected []string
+	}{
+		{"call mum #Home #family #home", []string{"home", "family"}},
+		{"#work: send the report", []string{"work"}},
+		{"купить молоко #покупки", []string{"покупки"}},
+		{"issue#12 and C# code", nil},
+		{"#thistagiswaytoolongtobeone", nil},
+	}
+	for _, tc := range tcs {
+		if res := extractTags(tc.in); !slices.Equal(res, tc.expected) {
+			t.Errorf("for '%s': expected %v, got %v", tc.in, tc.expected, res)
+		}
+	}
+	if tag, ok := parseTag(" #Work "); !ok || tag != "work" {
+		t.Errorf("expected #Work to refer to work, got %s", tag)
+	}
+	if _, ok := parseTag("#work #home"); ok {
+		t.Error("expected two tags not to refer to one")
+	}
+}
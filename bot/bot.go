@@ -1,11 +1,20 @@
 package bot
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/PaulSonOfLars/gotgbot/v2"
 	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
 	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers/filters/callbackquery"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers/filters/message"
 	"github.com/rs/zerolog/log"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 type WebhookBot struct {
@@ -14,6 +23,9 @@ type WebhookBot struct {
 	dispatcher *gobot.Dispatcher
 	updater    *gobot.Updater
 	cmds       Commands
+	mux        *http.ServeMux
+	server     *http.Server
+	registered atomic.Bool
 }
 
 type Config struct {
@@ -22,17 +34,54 @@ type Config struct {
 	UrlPath      string `json:"urlPath"`
 	AuthToken    string `json:"authToken"`
 	SharedSecret string `json:"sharedSecret"`
+	// AllowedUsers and AllowedChats restrict who can use the bot. If none of
+	// these or Admins are set, anyone can use it.
+	AllowedUsers []int64 `json:"allowedUsers"`
+	AllowedChats []int64 `json:"allowedChats"`
+	// Admins can always use the bot, and can /allow and /deny others.
+	Admins []int64 `json:"admins"`
 }
 
 type Command struct {
 	gotgbot.BotCommand
 	LongDescription string
 	Func            handlers.Response
+	// Scopes are where the command is shown in the command menu. If nil, it's
+	// shown everywhere; if empty, it's not shown at all. The command can be
+	// used regardless of whether it's shown.
+	Scopes []gotgbot.BotCommandScope
+	// Descriptions and LongDescriptions hold translations of Description and
+	// LongDescription by language code.
+	Descriptions     map[string]string
+	LongDescriptions map[string]string
+	// Callback handles presses of inline keyboard buttons whose data was made
+	// by CallbackData with the command's name.
+	Callback handlers.Response
+	// Reply handles messages that aren't commands, from users in a
+	// conversation the command started with StartConversation.
+	Reply handlers.Response
 }
 
-func NewWebhookBot(c *Config, cmds Commands) (*WebhookBot, error) {
+const callbackSep = ":"
 
-	bot, err := gotgbot.NewBot(c.AuthToken, nil)
+// CallbackData returns inline keyboard button data that is routed to the
+// Callback of the named command, which can read args with CallbackArgs.
+func CallbackData(command string, args ...string) string {
+	return strings.Join(append([]string{command}, args...), callbackSep)
+}
+
+// CallbackArgs returns the args passed to CallbackData for the button press
+// being handled.
+func CallbackArgs(ctx *gobot.Context) []string {
+	if ctx.CallbackQuery == nil {
+		return nil
+	}
+	return strings.Split(ctx.CallbackQuery.Data, callbackSep)[1:]
+}
+
+func NewWebhookBot(c *Config, cmds Commands, mws ...Middleware) (*WebhookBot, error) {
+
+	bot, err := NewBot(c.AuthToken)
 	if err != nil {
 		return nil, err
 	}
@@ -40,32 +89,84 @@ func NewWebhookBot(c *Config, cmds Commands) (*WebhookBot, error) {
 	dispatcher := gobot.NewDispatcher(&gobot.DispatcherOpts{
 		// If an error is returned by a handler, log it and continue going.
 		Error: func(b *gotgbot.Bot, ctx *gobot.Context, err error) gobot.DispatcherAction {
-			log.Err(err).Msg("error occurred while handling update")
+			Logger(ctx).Err(err).Msg("error occurred while handling update")
 			return gobot.DispatcherActionNoop
 		},
 		MaxRoutines: gobot.DefaultMaxRoutines,
 	})
+	convs := newConversations(ConversationTimeout)
+	replies := make(map[string]handlers.Response)
 	for _, v := range cmds {
-		dispatcher.AddHandler(handlers.NewCommand(v.Command, v.Func))
+		f := chain(v.Func, mws)
+		name := v.Command
+		dispatcher.AddHandler(handlers.NewCommand(v.Command, func(b *gotgbot.Bot, ctx *gobot.Context) error {
+			ctx.Data[commandKey] = name
+			ctx.Data[conversationsKey] = convs
+			return f(b, ctx)
+		}))
+		if v.Reply != nil {
+			replies[name] = chain(v.Reply, mws)
+		}
+		if v.Callback == nil {
+			continue
+		}
+		cb := chain(v.Callback, mws)
+		dispatcher.AddHandler(handlers.NewCallback(callbackquery.Prefix(name+callbackSep), func(b *gotgbot.Bot, ctx *gobot.Context) error {
+			ctx.Data[commandKey] = name
+			ctx.Data[conversationsKey] = convs
+			return cb(b, ctx)
+		}))
 	}
+	dispatcher.AddHandler(handlers.NewMessage(isReply, func(b *gotgbot.Bot, ctx *gobot.Context) error {
+		key, ok := conversationKeyOf(ctx)
+		if !ok {
+			return nil
+		}
+		conv, ok := convs.get(key, time.Now())
+		if !ok {
+			return nil
+		}
+		r, ok := replies[conv.command]
+		if !ok {
+			return nil
+		}
+		ctx.Data[commandKey] = conv.command
+		ctx.Data[conversationsKey] = convs
+		return r(b, ctx)
+	}))
 	updater := gobot.NewUpdater(dispatcher, nil)
 	err = updater.AddWebhook(bot, c.UrlPath, &gobot.AddWebhookOpts{SecretToken: c.SharedSecret})
 	if err != nil {
 		return nil, err
 	}
 
-	return &WebhookBot{b: bot, dispatcher: dispatcher, updater: updater, c: c, cmds: cmds}, nil
+	mux := http.NewServeMux()
+	mux.Handle("/", updater.GetHandlerFunc("/"))
+
+	return &WebhookBot{b: bot, dispatcher: dispatcher, updater: updater, c: c, cmds: cmds, mux: mux}, nil
+}
+
+// isReply matches text messages that aren't commands, which might be part of
+// a conversation.
+func isReply(msg *gotgbot.Message) bool {
+	return message.Text(msg) && !message.Command(msg)
+}
+
+// Handle registers an additional HTTP handler on the listener that receives
+// webhook updates. It must be called before Start.
+func (b *WebhookBot) Handle(pattern string, handler http.Handler) {
+	b.mux.Handle(pattern, handler)
 }
 
 type Commands []Command
 
-func CommandsEqual(v1 []Command, v2 []gotgbot.BotCommand) bool {
+func CommandsEqual(v1 []gotgbot.BotCommand, v2 []gotgbot.BotCommand) bool {
 
 	if len(v1) != len(v2) {
 		return false
 	}
 	for i, v := range v1 {
-		if v2[i] != v.BotCommand {
+		if v2[i] != v {
 			return false
 		}
 	}
@@ -73,42 +174,149 @@ func CommandsEqual(v1 []Command, v2 []gotgbot.BotCommand) bool {
 	return true
 }
 
-func (c Commands) GetGobotCommands() []gotgbot.BotCommand {
+// scopeIncludes reports whether users in the inner scope are also in the
+// outer scope. Telegram shows the command list of the narrowest scope that
+// has one, so a scope's list must include the commands of every scope
+// containing it.
+func scopeIncludes(outer, inner gotgbot.MergedBotCommandScope) bool {
+
+	switch outer.Type {
+	case inner.Type:
+		return outer == inner
+	case "default":
+		return true
+	case "all_private_chats":
+		return inner.Type == "chat" && inner.ChatId > 0
+	case "all_group_chats":
+		return inner.Type == "all_chat_administrators" || inner.ChatId < 0
+	case "all_chat_administrators":
+		return inner.Type == "chat_administrators"
+	case "chat":
+		return (inner.Type == "chat_administrators" || inner.Type == "chat_member") && inner.ChatId == outer.ChatId
+	}
+	return false
+}
 
-	ret := make([]gotgbot.BotCommand, len(c))
-	for i, e := range c {
-		ret[i] = e.BotCommand
+func (c Command) scopes() []gotgbot.BotCommandScope {
+	if c.Scopes == nil {
+		return []gotgbot.BotCommandScope{gotgbot.BotCommandScopeDefault{}}
+	}
+	return c.Scopes
+}
+
+// GetGobotCommands returns the command menu for the given scope and language
+// code. An empty language code gives the default descriptions.
+func (c Commands) GetGobotCommands(scope gotgbot.BotCommandScope, lang string) []gotgbot.BotCommand {
+
+	inner := scope.MergeBotCommandScope()
+	ret := make([]gotgbot.BotCommand, 0, len(c))
+	for _, e := range c {
+		for _, s := range e.scopes() {
+			if !scopeIncludes(s.MergeBotCommandScope(), inner) {
+				continue
+			}
+			cmd := e.BotCommand
+			if d, ok := e.Descriptions[lang]; ok {
+				cmd.Description = d
+			}
+			ret = append(ret, cmd)
+			break
+		}
 	}
 	return ret
 }
 
+// menus returns every scope and language code combination the commands
+// declare.
+func (c Commands) menus() ([]gotgbot.BotCommandScope, []string) {
+
+	scopes := []gotgbot.BotCommandScope{gotgbot.BotCommandScopeDefault{}}
+	seenScopes := map[gotgbot.MergedBotCommandScope]bool{scopes[0].MergeBotCommandScope(): true}
+	langs := []string{""}
+	seenLangs := map[string]bool{"": true}
+	for _, e := range c {
+		for _, s := range e.Scopes {
+			if m := s.MergeBotCommandScope(); !seenScopes[m] {
+				seenScopes[m] = true
+				scopes = append(scopes, s)
+			}
+		}
+		for l := range e.Descriptions {
+			if !seenLangs[l] {
+				seenLangs[l] = true
+				langs = append(langs, l)
+			}
+		}
+	}
+	return scopes, langs
+}
+
+func (b *WebhookBot) syncCommands() error {
+
+	scopes, langs := b.cmds.menus()
+	for _, scope := range scopes {
+		for _, lang := range langs {
+			logger := log.With().Str("scope", scope.GetType()).Str("lang", lang).Logger()
+			oldCommands, err := b.b.GetMyCommands(&gotgbot.GetMyCommandsOpts{Scope: scope, LanguageCode: lang})
+			if err != nil {
+				return err
+			}
+			cmds := b.cmds.GetGobotCommands(scope, lang)
+			if CommandsEqual(cmds, oldCommands) {
+				continue
+			}
+			ok, err := b.b.SetMyCommands(cmds, &gotgbot.SetMyCommandsOpts{Scope: scope, LanguageCode: lang})
+			if err != nil {
+				return err
+			}
+			if !ok {
+				logger.Error().Msg("Non ok result when trying to update commands")
+				continue
+			}
+			logger.Info().Msg("Updated commands")
+		}
+	}
+	return nil
+}
+
 func (b *WebhookBot) Start() error {
-	oldCommands, err := b.b.GetMyCommands(nil)
+	err := b.syncCommands()
 	if err != nil {
 		return err
 	}
-	if !CommandsEqual(b.cmds, oldCommands) {
 
-		ok, err := b.b.SetMyCommands(b.cmds.GetGobotCommands(), nil)
-		if err != nil {
-			return err
-		}
-		if !ok {
-			log.Error().Msg("Non ok result when trying to update commands")
-			return nil
-		}
-		log.Info().Msg("Updated commands")
+	ln, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", b.c.ListenPort))
+	if err != nil {
+		return err
 	}
-
-	err = b.updater.StartServer(gobot.WebhookOpts{ListenAddr: fmt.Sprintf("0.0.0.0:%d", b.c.ListenPort), SecretToken: b.c.SharedSecret})
+	b.server = &http.Server{Handler: b.mux}
+	go func() {
+		err := b.server.Serve(ln)
+		if err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Err(err).Msg("http server failed")
+		}
+	}()
+	err = b.updater.SetAllBotWebhooks(b.c.Host, &gotgbot.SetWebhookOpts{SecretToken: b.c.SharedSecret})
 	if err != nil {
 		return err
 	}
-	return b.updater.SetAllBotWebhooks(b.c.Host, &gotgbot.SetWebhookOpts{SecretToken: b.c.SharedSecret})
+	b.registered.Store(true)
+	return nil
+}
+
+// WebhookRegistered reports whether Start successfully registered the
+// webhook with Telegram.
+func (b *WebhookBot) WebhookRegistered() bool {
+	return b.registered.Load()
 }
 
 func (b *WebhookBot) Stop() error {
 
+	if b.server != nil {
+		if err := b.server.Shutdown(context.Background()); err != nil {
+			return err
+		}
+	}
 	return b.updater.Stop()
 }
 
@@ -0,0 +1,69 @@
+package bot
+
+import (
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"testing"
+)
+
+func TestCommands_GetGobotCommands(t *testing.T) {
+
+	cmds := Commands{
+		{BotCommand: gotgbot.BotCommand{Command: "set", Description: "Set"}, Descriptions: map[string]string{"ru": "Создать"}},
+		{BotCommand: gotgbot.BotCommand{Command: "start", Description: "Start"}, Scopes: []gotgbot.BotCommandScope{gotgbot.BotCommandScopeAllPrivateChats{}}},
+		{BotCommand: gotgbot.BotCommand{Command: "allow", Description: "Allow"}, Scopes: []gotgbot.BotCommandScope{gotgbot.BotCommandScopeChat{ChatId: 42}}},
+		{BotCommand: gotgbot.BotCommand{Command: "hidden", Description: "Hidden"}, Scopes: []gotgbot.BotCommandScope{}},
+	}
+	names := func(bcs []gotgbot.BotCommand) []string {
+		var ret []string
+		for _, c := range bcs {
+			ret = append(ret, c.Command)
+		}
+		return ret
+	}
+	tcs := []struct {
+		name     string
+		scope    gotgbot.BotCommandScope
+		expected []string
+	}{
+		{"default", gotgbot.BotCommandScopeDefault{}, []string{"set"}},
+		{"private chats", gotgbot.BotCommandScopeAllPrivateChats{}, []string{"set", "start"}},
+		{"admin chat", gotgbot.BotCommandScopeChat{ChatId: 42}, []string{"set", "start", "allow"}},
+		{"group", gotgbot.BotCommandScopeAllGroupChats{}, []string{"set"}},
+	}
+	for _, tc := range tcs {
+		t.Run(tc.name, func(t *testing.T) {
+			got := names(cmds.GetGobotCommands(tc.scope, ""))
+			if len(got) != len(tc.expected) {
+				t.Fatalf("Expected %v, got %v", tc.expected, got)
+			}
+			for i := range got {
+				if got[i] != tc.expected[i] {
+					t.Fatalf("Expected %v, got %v", tc.expected, got)
+				}
+			}
+		})
+	}
+
+	ru := cmds.GetGobotCommands(gotgbot.BotCommandScopeDefault{}, "ru")
+	if ru[0].Description != "Создать" {
+		t.Errorf("Expected localized description, got '%s'", ru[0].Description)
+	}
+	scopes, langs := cmds.menus()
+	if len(scopes) != 3 || len(langs) != 2 {
+		t.Errorf("Expected 3 scopes and 2 languages, got %d and %d", len(scopes), len(langs))
+	}
+}
+
+func TestCallbackArgs(t *testing.T) {
+
+	data := CallbackData("set", "a1", "0")
+	if data != "set:a1:0" {
+		t.Fatalf("unexpected callback data '%s'", data)
+	}
+	ctx := &gobot.Context{Update: &gotgbot.Update{CallbackQuery: &gotgbot.CallbackQuery{Data: data}}}
+	args := CallbackArgs(ctx)
+	if len(args) != 2 || args[0] != "a1" || args[1] != "0" {
+		t.Errorf("unexpected callback args %v", args)
+	}
+}
@@ -0,0 +1,112 @@
+package bot
+
+import (
+	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"sync"
+	"time"
+)
+
+// ConversationTimeout is how long a conversation waits for the user's next
+// message before it's forgotten.
+const ConversationTimeout = 10 * time.Minute
+
+const conversationsKey = "conversations"
+
+type conversationKey struct {
+	chat, user int64
+}
+
+type conversation struct {
+	command string
+	state   any
+	expires time.Time
+}
+
+// conversations holds the state of the conversations each user is having
+// with the bot, per chat.
+type conversations struct {
+	mu      sync.Mutex
+	timeout time.Duration
+	states  map[conversationKey]conversation
+}
+
+func newConversations(timeout time.Duration) *conversations {
+	return &conversations{timeout: timeout, states: make(map[conversationKey]conversation)}
+}
+
+func (c *conversations) set(key conversationKey, command string, state any, now time.Time) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, v := range c.states {
+		if now.After(v.expires) {
+			delete(c.states, k)
+		}
+	}
+	c.states[key] = conversation{command, state, now.Add(c.timeout)}
+}
+
+func (c *conversations) get(key conversationKey, now time.Time) (conversation, bool) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.states[key]
+	if !ok || now.After(v.expires) {
+		return conversation{}, false
+	}
+	return v, true
+}
+
+func (c *conversations) end(key conversationKey) {
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.states, key)
+}
+
+func conversationKeyOf(ctx *gobot.Context) (conversationKey, bool) {
+	if ctx.EffectiveChat == nil || ctx.EffectiveSender == nil {
+		return conversationKey{}, false
+	}
+	return conversationKey{ctx.EffectiveChat.Id, ctx.EffectiveSender.Id()}, true
+}
+
+func conversationsOf(ctx *gobot.Context) (*conversations, conversationKey, bool) {
+	c, ok := ctx.Data[conversationsKey].(*conversations)
+	if !ok {
+		return nil, conversationKey{}, false
+	}
+	key, ok := conversationKeyOf(ctx)
+	return c, key, ok
+}
+
+// StartConversation sends the sender's next non-command message in this chat
+// to the Reply of the command being handled, which can get state back with
+// Conversation. Starting a conversation replaces any other one the sender
+// is having in the chat.
+func StartConversation(ctx *gobot.Context, state any) {
+	if c, key, ok := conversationsOf(ctx); ok {
+		c.set(key, CommandName(ctx), state, time.Now())
+	}
+}
+
+// Conversation returns the state of the sender's conversation in this chat,
+// if they're having one with the command being handled.
+func Conversation(ctx *gobot.Context) (any, bool) {
+	c, key, ok := conversationsOf(ctx)
+	if !ok {
+		return nil, false
+	}
+	v, ok := c.get(key, time.Now())
+	if !ok || v.command != CommandName(ctx) {
+		return nil, false
+	}
+	return v.state, true
+}
+
+// EndConversation forgets the sender's conversation in this chat.
+func EndConversation(ctx *gobot.Context) {
+	if c, key, ok := conversationsOf(ctx); ok {
+		c.end(key)
+	}
+}
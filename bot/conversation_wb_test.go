@@ -0,0 +1,52 @@
+package bot
+
+import (
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"testing"
+	"time"
+)
+
+func conversationContext(convs *conversations, command string, chat, user int64) *gobot.Context {
+	return &gobot.Context{
+		EffectiveChat:   &gotgbot.Chat{Id: chat},
+		EffectiveSender: &gotgbot.Sender{User: &gotgbot.User{Id: user}},
+		Data:            map[string]interface{}{commandKey: command, conversationsKey: convs},
+	}
+}
+
+func TestConversation(t *testing.T) {
+
+	convs := newConversations(time.Minute)
+	ctx := conversationContext(convs, "set", 1, 2)
+	StartConversation(ctx, "what")
+
+	state, ok := Conversation(ctx)
+	if !ok || state != "what" {
+		t.Fatalf("expected conversation state 'what', got %v, %v", state, ok)
+	}
+	if _, ok = Conversation(conversationContext(convs, "set", 1, 3)); ok {
+		t.Error("expected no conversation for another user")
+	}
+	if _, ok = Conversation(conversationContext(convs, "list", 1, 2)); ok {
+		t.Error("expected no conversation for another command")
+	}
+	EndConversation(ctx)
+	if _, ok = Conversation(ctx); ok {
+		t.Error("expected conversation to have ended")
+	}
+}
+
+func TestConversation_Timeout(t *testing.T) {
+
+	convs := newConversations(time.Minute)
+	key := conversationKey{1, 2}
+	now := time.Now()
+	convs.set(key, "set", "when", now)
+	if _, ok := convs.get(key, now.Add(30*time.Second)); !ok {
+		t.Error("expected conversation before timeout")
+	}
+	if _, ok := convs.get(key, now.Add(2*time.Minute)); ok {
+		t.Error("expected conversation to have timed out")
+	}
+}
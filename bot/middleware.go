@@ -0,0 +1,196 @@
+package bot
+
+import (
+	"fmt"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"github.com/henges/later/metrics"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"runtime/debug"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Middleware wraps a command's handler. Middlewares passed to NewWebhookBot
+// are applied in order, so the first one is the outermost.
+type Middleware func(next handlers.Response) handlers.Response
+
+const (
+	commandKey   = "command"
+	requestIDKey = "requestID"
+	loggerKey    = "logger"
+)
+
+func chain(f handlers.Response, mws []Middleware) handlers.Response {
+	for i := len(mws) - 1; i >= 0; i-- {
+		f = mws[i](f)
+	}
+	return f
+}
+
+// CommandName returns the name of the command being handled.
+func CommandName(ctx *gobot.Context) string {
+	s, _ := ctx.Data[commandKey].(string)
+	return s
+}
+
+// RequestID returns the ID assigned to the update by the RequestID
+// middleware.
+func RequestID(ctx *gobot.Context) string {
+	s, _ := ctx.Data[requestIDKey].(string)
+	return s
+}
+
+// Logger returns the logger set up by the Logging middleware, or the global
+// logger if there isn't one.
+func Logger(ctx *gobot.Context) *zerolog.Logger {
+	if l, ok := ctx.Data[loggerKey].(*zerolog.Logger); ok {
+		return l
+	}
+	return &log.Logger
+}
+
+// WithRequestID tags each update with an ID, available through RequestID.
+func WithRequestID() Middleware {
+	return func(next handlers.Response) handlers.Response {
+		return func(b *gotgbot.Bot, ctx *gobot.Context) error {
+			ctx.Data[requestIDKey] = strconv.FormatInt(ctx.UpdateId, 10)
+			return next(b, ctx)
+		}
+	}
+}
+
+// WithLogging builds a logger describing the update, available through
+// Logger, and logs the update. Errors returned by handlers are logged with
+// this logger by the dispatcher.
+func WithLogging() Middleware {
+	return func(next handlers.Response) handlers.Response {
+		return func(b *gotgbot.Bot, ctx *gobot.Context) error {
+			lc := log.With().Str("command", CommandName(ctx))
+			if id := RequestID(ctx); id != "" {
+				lc = lc.Str("requestID", id)
+			}
+			if ctx.EffectiveMessage != nil {
+				lc = lc.Str("messageBody", ctx.EffectiveMessage.Text)
+			}
+			if ctx.EffectiveSender != nil {
+				lc = lc.Str("username", ctx.EffectiveSender.Username())
+			}
+			logger := lc.Logger()
+			ctx.Data[loggerKey] = &logger
+
+			logger.Trace().Msg("Handle update")
+			return next(b, ctx)
+		}
+	}
+}
+
+// WithRecovery turns panics in handlers into errors.
+func WithRecovery() Middleware {
+	return func(next handlers.Response) handlers.Response {
+		return func(b *gotgbot.Bot, ctx *gobot.Context) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					Logger(ctx).Error().Interface("panic", r).Bytes("stack", debug.Stack()).Msg("recovered from panic in handler")
+					err = fmt.Errorf("panic in handler: %v", r)
+				}
+			}()
+			return next(b, ctx)
+		}
+	}
+}
+
+// WithAuth only passes on updates for which allow returns true. Rejected
+// updates are passed to reject, if it isn't nil.
+func WithAuth(allow func(ctx *gobot.Context) bool, reject handlers.Response) Middleware {
+	return func(next handlers.Response) handlers.Response {
+		return func(b *gotgbot.Bot, ctx *gobot.Context) error {
+			if allow(ctx) {
+				return next(b, ctx)
+			}
+			Logger(ctx).Debug().Msg("Rejected unauthorised update")
+			if reject != nil {
+				return reject(b, ctx)
+			}
+			return nil
+		}
+	}
+}
+
+// WithRateLimit drops updates from a sender that arrive less than interval
+// after their previous accepted update.
+func WithRateLimit(interval time.Duration) Middleware {
+	rl := newRateLimiter(interval)
+	return func(next handlers.Response) handlers.Response {
+		return func(b *gotgbot.Bot, ctx *gobot.Context) error {
+			if ctx.EffectiveSender == nil {
+				return next(b, ctx)
+			}
+			if !rl.allow(ctx.EffectiveSender.Id(), time.Now()) {
+				Logger(ctx).Debug().Msg("Dropped rate limited update")
+				return nil
+			}
+			return next(b, ctx)
+		}
+	}
+}
+
+// rateLimiter remembers when each sender's last accepted update arrived.
+// Senders quiet for longer than interval are forgotten, so it only grows with
+// the number of recently active senders.
+type rateLimiter struct {
+	interval time.Duration
+	mu       sync.Mutex
+	last     map[int64]time.Time
+	swept    time.Time
+}
+
+func newRateLimiter(interval time.Duration) *rateLimiter {
+	return &rateLimiter{interval: interval, last: make(map[int64]time.Time)}
+}
+
+func (rl *rateLimiter) allow(id int64, now time.Time) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	// Sweeping at most once an interval keeps this cheap when busy.
+	if now.Sub(rl.swept) >= rl.interval {
+		for k, t := range rl.last {
+			if now.Sub(t) >= rl.interval {
+				delete(rl.last, k)
+			}
+		}
+		rl.swept = now
+	}
+	if prev, seen := rl.last[id]; seen && now.Sub(prev) < rl.interval {
+		return false
+	}
+	rl.last[id] = now
+	return true
+}
+
+// WithTiming records how long each command takes to handle.
+func WithTiming() Middleware {
+	return func(next handlers.Response) handlers.Response {
+		return func(b *gotgbot.Bot, ctx *gobot.Context) error {
+			start := time.Now()
+			err := next(b, ctx)
+			metrics.HandlerDuration.WithLabelValues(CommandName(ctx)).Observe(time.Since(start).Seconds())
+			return err
+		}
+	}
+}
+
+// DefaultMiddleware is the middleware chain used for the bot's commands.
+// Updates from a sender less than rateLimit apart are dropped, unless it's
+// zero.
+func DefaultMiddleware(rateLimit time.Duration) []Middleware {
+	mws := []Middleware{WithRequestID(), WithLogging(), WithRecovery()}
+	if rateLimit > 0 {
+		mws = append(mws, WithRateLimit(rateLimit))
+	}
+	return append(mws, WithTiming())
+}
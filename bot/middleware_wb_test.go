@@ -0,0 +1,70 @@
+package bot
+
+import (
+	"errors"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	gobot "github.com/PaulSonOfLars/gotgbot/v2/ext"
+	"github.com/PaulSonOfLars/gotgbot/v2/ext/handlers"
+	"testing"
+	"time"
+)
+
+func TestChain(t *testing.T) {
+
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next handlers.Response) handlers.Response {
+			return func(b *gotgbot.Bot, ctx *gobot.Context) error {
+				order = append(order, name)
+				return next(b, ctx)
+			}
+		}
+	}
+	f := chain(func(b *gotgbot.Bot, ctx *gobot.Context) error {
+		order = append(order, "handler")
+		return nil
+	}, []Middleware{mw("first"), mw("second")})
+
+	if err := f(nil, &gobot.Context{Data: map[string]interface{}{}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 3 || order[0] != "first" || order[1] != "second" || order[2] != "handler" {
+		t.Errorf("Wrong order: %v", order)
+	}
+}
+
+func TestWithRecovery(t *testing.T) {
+
+	f := chain(func(b *gotgbot.Bot, ctx *gobot.Context) error {
+		panic(errors.New("oh no"))
+	}, []Middleware{WithRecovery()})
+
+	err := f(nil, &gobot.Context{Data: map[string]interface{}{}})
+	if err == nil {
+		t.Error("Expected panic to be returned as an error")
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+
+	rl := newRateLimiter(time.Second)
+	start := time.Unix(1000, 0)
+
+	if !rl.allow(1, start) {
+		t.Error("Expected first update to be allowed")
+	}
+	if rl.allow(1, start.Add(500*time.Millisecond)) {
+		t.Error("Expected update within interval to be dropped")
+	}
+	if !rl.allow(2, start.Add(500*time.Millisecond)) {
+		t.Error("Expected another sender's update to be allowed")
+	}
+	if !rl.allow(1, start.Add(time.Second)) {
+		t.Error("Expected update after interval to be allowed")
+	}
+	// Both senders have been quiet for an interval, so they're forgotten.
+	rl.allow(3, start.Add(3*time.Second))
+	if len(rl.last) != 1 {
+		t.Errorf("Expected stale senders to be evicted, have %v", rl.last)
+	}
+}
@@ -0,0 +1,165 @@
+package bot
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Limits from https://core.telegram.org/bots/faq#my-bot-is-hitting-limits-how-do-i-avoid-this
+const (
+	defaultChatInterval   = time.Second
+	defaultGroupInterval  = 3 * time.Second
+	defaultGlobalInterval = time.Second / 30
+	defaultMaxAttempts    = 5
+	globalKey             = "*"
+	// pruneThreshold is the number of tracked chats above which stale
+	// entries are removed.
+	pruneThreshold = 1024
+)
+
+// SendQueue is a gotgbot.BotClient that queues outgoing messages so that
+// Telegram's per-chat and global rate limits aren't exceeded. Requests that
+// are rejected with a 429 are retried after the interval Telegram asks for.
+// Callers block until their request has finally succeeded or failed.
+type SendQueue struct {
+	gotgbot.BotClient
+	ChatInterval   time.Duration
+	GroupInterval  time.Duration
+	GlobalInterval time.Duration
+	MaxAttempts    int
+
+	mu   sync.Mutex
+	next map[string]time.Time
+}
+
+func NewSendQueue(client gotgbot.BotClient) *SendQueue {
+	return &SendQueue{
+		BotClient:      client,
+		ChatInterval:   defaultChatInterval,
+		GroupInterval:  defaultGroupInterval,
+		GlobalInterval: defaultGlobalInterval,
+		MaxAttempts:    defaultMaxAttempts,
+		next:           make(map[string]time.Time),
+	}
+}
+
+// NewBot creates a bot whose requests are sent through a SendQueue.
+func NewBot(token string) (*gotgbot.Bot, error) {
+	return gotgbot.NewBot(token, &gotgbot.BotOpts{
+		BotClient: NewSendQueue(&gotgbot.BaseBotClient{Client: http.Client{}}),
+	})
+}
+
+func isRateLimited(method string) bool {
+	for _, p := range []string{"send", "edit", "copy", "forward"} {
+		if strings.HasPrefix(method, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func (q *SendQueue) RequestWithContext(ctx context.Context, token string, method string, params map[string]string, data map[string]gotgbot.FileReader, opts *gotgbot.RequestOpts) (json.RawMessage, error) {
+
+	limited := isRateLimited(method)
+	chat := params["chat_id"]
+	chatInterval := q.ChatInterval
+	if strings.HasPrefix(chat, "-") {
+		chatInterval = q.GroupInterval
+	}
+
+	var err error
+	for attempt := 1; attempt <= q.MaxAttempts; attempt++ {
+		if limited {
+			if chat != "" {
+				if err = q.wait(ctx, chat, chatInterval); err != nil {
+					return nil, err
+				}
+			}
+			if err = q.wait(ctx, globalKey, q.GlobalInterval); err != nil {
+				return nil, err
+			}
+		}
+
+		var res json.RawMessage
+		res, err = q.BotClient.RequestWithContext(ctx, token, method, params, data, opts)
+		var tgErr *gotgbot.TelegramError
+		if !errors.As(err, &tgErr) || tgErr.Code != http.StatusTooManyRequests || attempt == q.MaxAttempts {
+			return res, err
+		}
+
+		retryAfter := time.Second
+		if tgErr.ResponseParams != nil && tgErr.ResponseParams.RetryAfter > 0 {
+			retryAfter = time.Duration(tgErr.ResponseParams.RetryAfter) * time.Second
+		}
+		log.Warn().Str("method", method).Str("chat", chat).Dur("retryAfter", retryAfter).Int("attempt", attempt).
+			Msg("rate limited by telegram")
+		key := chat
+		if key == "" {
+			key = globalKey
+		}
+		q.delay(key, retryAfter)
+		if !limited {
+			if err = sleepCtx(ctx, retryAfter); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return nil, err
+}
+
+// wait blocks until the next slot for key is reached, then reserves the slot
+// after it.
+func (q *SendQueue) wait(ctx context.Context, key string, interval time.Duration) error {
+
+	q.mu.Lock()
+	now := time.Now()
+	slot := q.next[key]
+	if slot.Before(now) {
+		slot = now
+	}
+	q.next[key] = slot.Add(interval)
+	if len(q.next) > pruneThreshold {
+		for k, v := range q.next {
+			if v.Before(now) {
+				delete(q.next, k)
+			}
+		}
+	}
+	q.mu.Unlock()
+
+	return sleepCtx(ctx, time.Until(slot))
+}
+
+// delay pushes back the next slot for key by at least d from now.
+func (q *SendQueue) delay(key string, d time.Duration) {
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	until := time.Now().Add(d)
+	if q.next[key].Before(until) {
+		q.next[key] = until
+	}
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) error {
+
+	if d <= 0 {
+		return nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,78 @@
+package bot_test
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/PaulSonOfLars/gotgbot/v2"
+	"github.com/henges/later/bot"
+	"net/http"
+	"testing"
+	"time"
+)
+
+type fakeClient struct {
+	gotgbot.BotClient
+	calls     []time.Time
+	failFirst int
+}
+
+func (f *fakeClient) RequestWithContext(_ context.Context, _ string, method string, _ map[string]string, _ map[string]gotgbot.FileReader, _ *gotgbot.RequestOpts) (json.RawMessage, error) {
+	f.calls = append(f.calls, time.Now())
+	if len(f.calls) <= f.failFirst {
+		return nil, &gotgbot.TelegramError{
+			Method:         method,
+			Code:           http.StatusTooManyRequests,
+			ResponseParams: &gotgbot.ResponseParameters{RetryAfter: 1},
+		}
+	}
+	return json.RawMessage("true"), nil
+}
+
+func TestSendQueue_PerChatLimit(t *testing.T) {
+
+	fc := &fakeClient{}
+	q := bot.NewSendQueue(fc)
+	q.ChatInterval = 100 * time.Millisecond
+	params := map[string]string{"chat_id": "1"}
+	for range 3 {
+		if _, err := q.RequestWithContext(context.Background(), "", "sendMessage", params, nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if elapsed := fc.calls[2].Sub(fc.calls[0]); elapsed < 200*time.Millisecond {
+		t.Errorf("Expected sends to the same chat to be spaced out, took %s", elapsed)
+	}
+
+	start := time.Now()
+	_, err := q.RequestWithContext(context.Background(), "", "sendMessage", map[string]string{"chat_id": "2"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("Expected send to another chat not to wait, took %s", elapsed)
+	}
+}
+
+func TestSendQueue_RetryAfter(t *testing.T) {
+
+	fc := &fakeClient{failFirst: 1}
+	q := bot.NewSendQueue(fc)
+	_, err := q.RequestWithContext(context.Background(), "", "sendMessage", map[string]string{"chat_id": "1"}, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fc.calls) != 2 {
+		t.Fatal("Expected one retry, got calls:", len(fc.calls))
+	}
+	if elapsed := fc.calls[1].Sub(fc.calls[0]); elapsed < time.Second {
+		t.Errorf("Expected retry to honour retry_after, took %s", elapsed)
+	}
+
+	fc = &fakeClient{failFirst: 10}
+	q = bot.NewSendQueue(fc)
+	q.MaxAttempts = 2
+	_, err = q.RequestWithContext(context.Background(), "", "sendMessage", map[string]string{"chat_id": "1"}, nil, nil)
+	if err == nil {
+		t.Error("Expected error once attempts are exhausted")
+	}
+}
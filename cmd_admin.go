@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/henges/later/app"
+	"github.com/henges/later/bot"
+	"github.com/henges/later/later"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+func printReminders(rmds []later.SavedReminder) error {
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tOWNER\tFIRE TIME\tDATA")
+	for _, r := range rmds {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", r.ID, r.Owner, r.FireTime.Format(time.RFC3339), r.CallbackData)
+	}
+	return tw.Flush()
+}
+
+func runList(g *globals, args []string) error {
+
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	owner := fs.String("owner", "", "only list reminders belonging to this owner")
+	_ = fs.Parse(args)
+
+	l, err := g.openLater()
+	if err != nil {
+		return err
+	}
+	var rmds []later.SavedReminder
+	if *owner != "" {
+		rmds, err = l.GetRemindersByOwner(*owner)
+	} else {
+		rmds, err = l.GetAllReminders()
+	}
+	if err != nil {
+		return err
+	}
+	return printReminders(rmds)
+}
+
+func runAdd(g *globals, args []string) error {
+
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	owner := fs.String("owner", "", "owner of the reminder (required)")
+	at := fs.String("at", "", "RFC3339 time at which the reminder fires (required)")
+	name := fs.String("name", "", "description of the reminder")
+	chat := fs.Int64("chat", 0, "telegram chat ID the reminder is sent to")
+	data := fs.String("data", "", "raw callback data, overriding -name and -chat")
+	_ = fs.Parse(args)
+
+	if *owner == "" || *at == "" {
+		fs.Usage()
+		return errors.New("-owner and -at are required")
+	}
+	fireTime, err := time.Parse(time.RFC3339, *at)
+	if err != nil {
+		return err
+	}
+	cbd := *data
+	if cbd == "" {
+		b, err := json.Marshal(app.TelegramCallbackData{Name: *name, ReplyTo: *chat})
+		if err != nil {
+			return err
+		}
+		cbd = string(b)
+	}
+
+	l, err := g.openLater()
+	if err != nil {
+		return err
+	}
+	id, err := l.InsertReminder(later.Reminder{Owner: *owner, FireTime: fireTime, CallbackData: cbd})
+	if err != nil {
+		return err
+	}
+	fmt.Println(id)
+	return nil
+}
+
+func runDelete(g *globals, args []string) error {
+
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	owner := fs.String("owner", "", "owner of the reminder (required)")
+	_ = fs.Parse(args)
+
+	if *owner == "" || fs.NArg() != 1 {
+		return errors.New("usage: later delete -owner <owner> <id>")
+	}
+	id, err := strconv.ParseInt(fs.Arg(0), 10, 64)
+	if err != nil {
+		return err
+	}
+
+	l, err := g.openLater()
+	if err != nil {
+		return err
+	}
+	deleted, err := l.DeleteReminderWithOwner(*owner, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return fmt.Errorf("no reminder with ID %d belonging to %s", id, *owner)
+	}
+	return nil
+}
+
+func runMigrate(g *globals, _ []string) error {
+
+	// Opening the database applies the schema.
+	_, err := g.openLater()
+	return err
+}
+
+func runExport(g *globals, _ []string) error {
+
+	l, err := g.openLater()
+	if err != nil {
+		return err
+	}
+	rmds, err := l.GetAllReminders()
+	if err != nil {
+		return err
+	}
+	if rmds == nil {
+		rmds = []later.SavedReminder{}
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(rmds)
+}
+
+func runImport(g *globals, _ []string) error {
+
+	var rmds []later.Reminder
+	if err := json.NewDecoder(os.Stdin).Decode(&rmds); err != nil {
+		return err
+	}
+
+	l, err := g.openLater()
+	if err != nil {
+		return err
+	}
+	// IDs are not preserved; each reminder is inserted as a new row. If any
+	// can't be, none are.
+	if _, err = l.InsertReminders(rmds); err != nil {
+		return fmt.Errorf("nothing imported: %w", err)
+	}
+	fmt.Printf("Imported %d reminders\n", len(rmds))
+	return nil
+}
+
+func runFireDue(g *globals, args []string) error {
+
+	fs := flag.NewFlagSet("fire-due", flag.ExitOnError)
+	dryRun := fs.Bool("dry-run", false, "only print the reminders that would fire")
+	_ = fs.Parse(args)
+
+	l, err := g.openLater()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	if *dryRun {
+		rmds, err := l.GetRemindersDueAt(now)
+		if err != nil {
+			return err
+		}
+		return printReminders(rmds)
+	}
+
+	if err = g.conf.Validate(); err != nil {
+		return fmt.Errorf("invalid config:\n%w", err)
+	}
+	b, err := bot.NewBot(g.conf.AuthToken)
+	if err != nil {
+		return err
+	}
+	l.SetHold(app.NewQuietHold(l))
+	l.SetCallback(app.NewReminderCallback(l, b))
+	return l.FireDueReminders(now)
+}
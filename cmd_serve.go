@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/henges/later/api"
+	"github.com/henges/later/app"
+	"github.com/henges/later/bot"
+	"github.com/henges/later/health"
+	"github.com/henges/later/metrics"
+	"github.com/henges/later/rpc"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"net"
+	"net/http"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+func runServe(g *globals, _ []string) error {
+
+	conf := g.conf
+	if err := conf.Validate(); err != nil {
+		return fmt.Errorf("invalid config:\n%w", err)
+	}
+	loc, err := conf.Location()
+	if err != nil {
+		return err
+	}
+	app.SetDefaultTimezone(loc)
+	l, err := g.openLater()
+	if err != nil {
+		return err
+	}
+	p := app.NewParsers()
+	undos := app.NewUndoLog()
+	access := app.NewAccessControl(l, &conf.Config)
+	cmds := bot.Commands{
+		app.NewSetReminderCommand(l, p),
+		app.NewListRemindersCommand(l, p, undos),
+		app.NewDeleteReminderCommand(l, p, undos),
+		app.NewClearCommand(l, undos),
+		app.NewUndoCommand(l, undos),
+		app.NewFindCommand(l),
+		app.NewHistoryCommand(l),
+		app.NewTodayCommand(l),
+		app.NewTomorrowCommand(l),
+		app.NewWeekCommand(l),
+		app.NewDigestCommand(l, p),
+		app.NewQuietCommand(l),
+		app.NewAllowCommand(access),
+		app.NewDenyCommand(access),
+		app.NewLangCommand(l),
+	}
+	cmds = append(cmds, app.NewHelpCommand(l, cmds))
+	cmds = append(cmds, app.NewStartCommand(l))
+	mws := append(bot.DefaultMiddleware(time.Duration(conf.RateLimit)), access.Middleware())
+	webhookBot, err := bot.NewWebhookBot(&conf.Config, cmds, mws...)
+	if err != nil {
+		return err
+	}
+	pollInterval := time.Duration(conf.PollInterval)
+	// The API shares the webhook listener unless it's given its own port. The
+	// health and metrics endpoints are served on the admin address, or with
+	// the API if there isn't one.
+	var mux api.Handler = webhookBot
+	var apiMux *http.ServeMux
+	if conf.Api.ListenPort != 0 {
+		apiMux = http.NewServeMux()
+		mux = apiMux
+	}
+	api.NewServer(l, &conf.Api).Register(mux)
+	var adminMux *http.ServeMux
+	if conf.AdminListenAddr != "" {
+		adminMux = http.NewServeMux()
+		mux = adminMux
+	}
+	metrics.RegisterPendingReminders(l.CountReminders)
+	mux.Handle("GET /metrics", metrics.Handler())
+	health.Register(mux, []health.Check{
+		{Name: "database", Func: l.Ping},
+		{Name: "webhook", Func: func(context.Context) error {
+			if !webhookBot.WebhookRegistered() {
+				return errors.New("webhook not registered")
+			}
+			return nil
+		}},
+		{Name: "poller", Func: func(context.Context) error {
+			if last := l.LastPoll(); time.Since(last) > 3*pollInterval {
+				return fmt.Errorf("poller last ticked at %s", last.Format(time.RFC3339))
+			}
+			return nil
+		}},
+	})
+	var apiHttpServer *http.Server
+	if apiMux != nil {
+		apiHttpServer = &http.Server{Addr: fmt.Sprintf("0.0.0.0:%d", conf.Api.ListenPort), Handler: apiMux}
+		go func() {
+			err := apiHttpServer.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatal().Err(err).Send()
+			}
+		}()
+	}
+	var adminHttpServer *http.Server
+	if adminMux != nil {
+		adminHttpServer = &http.Server{Addr: conf.AdminListenAddr, Handler: adminMux}
+		go func() {
+			err := adminHttpServer.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatal().Err(err).Send()
+			}
+		}()
+	}
+	var rpcServer *rpc.Server
+	var grpcServer *grpc.Server
+	if conf.Rpc.ListenPort != 0 {
+		ln, err := net.Listen("tcp", fmt.Sprintf("0.0.0.0:%d", conf.Rpc.ListenPort))
+		if err != nil {
+			return err
+		}
+		rpcServer = rpc.NewServer(l, &conf.Rpc)
+		grpcServer = grpc.NewServer(rpcServer.ServerOptions()...)
+		rpcServer.Register(grpcServer)
+		go func() {
+			err := grpcServer.Serve(ln)
+			if err != nil {
+				log.Fatal().Err(err).Send()
+			}
+		}()
+	}
+	webhookBot.Start()
+	cb := app.NewReminderCallback(l, webhookBot.GetBot())
+	if rpcServer != nil {
+		cb = rpcServer.Callback(cb)
+	}
+	err = app.StartPolling(l, cb, pollInterval)
+	if err != nil {
+		return err
+	}
+	defer l.StopPoll()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	log.Info().Msg("App ready")
+
+	<-ctx.Done()
+	stop()
+	if grpcServer != nil {
+		// Watch streams don't end on their own, so they're ended first.
+		rpcServer.Stop()
+		grpcServer.GracefulStop()
+	}
+	if apiHttpServer != nil {
+		err = apiHttpServer.Shutdown(context.Background())
+		if err != nil {
+			log.Err(err).Msg("while stopping api server")
+		}
+	}
+	if adminHttpServer != nil {
+		err = adminHttpServer.Shutdown(context.Background())
+		if err != nil {
+			log.Err(err).Msg("while stopping admin server")
+		}
+	}
+	err = webhookBot.Stop()
+	log.Info().Err(err).Msg("App shutdown")
+	return nil
+}
@@ -0,0 +1,262 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/henges/later/api"
+	"github.com/henges/later/app"
+	"github.com/henges/later/bot"
+	"github.com/henges/later/later"
+	"github.com/henges/later/rpc"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// DefaultPath is the config file read when no path is given. Unlike an
+// explicitly given path, it's not an error for it to be missing.
+const DefaultPath = "./config.json"
+
+type Config struct {
+	bot.Config
+	Api          api.Config `json:"api"`
+	Rpc          rpc.Config `json:"rpc"`
+	DBName       string     `json:"dbName"`
+	LogLevel     string     `json:"logLevel"`
+	LogFormat    string     `json:"logFormat"`
+	PollInterval Duration   `json:"pollInterval"`
+	Timezone     string     `json:"timezone"`
+	Limits       Limits     `json:"limits"`
+	// HistoryRetention is how long fired reminders are kept in history. Zero
+	// keeps them forever.
+	HistoryRetention Duration `json:"historyRetention"`
+	// DeletedRetention is how long deleted reminders are kept before they're
+	// purged. Zero keeps them forever; otherwise it can't be shorter than
+	// deletions can be undone for.
+	DeletedRetention Duration `json:"deletedRetention"`
+	// AdminListenAddr is the address /metrics, /healthz and /readyz are
+	// served on. If it's empty they're served with the API instead, where
+	// anyone who can reach the webhook can see them.
+	AdminListenAddr string `json:"adminListenAddr"`
+	// RateLimit is how far apart a user's updates have to be for the bot to
+	// handle them. Zero handles them all.
+	RateLimit Duration `json:"rateLimit"`
+}
+
+// Limits mirrors later.Limits. Zero values mean unlimited.
+type Limits struct {
+	MaxPendingPerOwner int      `json:"maxPendingPerOwner"`
+	MaxHorizon         Duration `json:"maxHorizon"`
+	// MaxDataLength bounds the stored reminder data in bytes. It counts the
+	// whole JSON the bot stores, so descriptions have to be a little shorter.
+	MaxDataLength int `json:"maxDataLength"`
+	// MaxDescriptionLength bounds what users type as a description, in
+	// characters.
+	MaxDescriptionLength int `json:"maxDescriptionLength"`
+}
+
+func (l Limits) Later() later.Limits {
+	return later.Limits{
+		MaxPendingPerOwner:   l.MaxPendingPerOwner,
+		MaxHorizon:           time.Duration(l.MaxHorizon),
+		MaxDataLength:        l.MaxDataLength,
+		MaxDescriptionLength: l.MaxDescriptionLength,
+	}
+}
+
+// Duration is a time.Duration that is read from JSON as a string such as
+// "1s" or "500ms".
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(b []byte) error {
+
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+func Default() *Config {
+	return &Config{
+		Config:           bot.Config{ListenPort: 23150},
+		DBName:           "file:later.db",
+		LogLevel:         "trace",
+		LogFormat:        "json",
+		PollInterval:     Duration(time.Second),
+		Timezone:         "Australia/Perth",
+		HistoryRetention: Duration(30 * 24 * time.Hour),
+		DeletedRetention: Duration(24 * time.Hour),
+		AdminListenAddr:  "127.0.0.1:23151",
+		RateLimit:        Duration(500 * time.Millisecond),
+	}
+}
+
+// Load builds a Config from the defaults, overlaid with the file at path,
+// overlaid with LATER_* environment variables. If path is empty, LATER_CONFIG
+// or DefaultPath is used.
+func Load(path string) (*Config, error) {
+
+	c := Default()
+	if path == "" {
+		path = os.Getenv("LATER_CONFIG")
+	}
+	optional := path == ""
+	if optional {
+		path = DefaultPath
+	}
+	file, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err = json.Unmarshal(file, c); err != nil {
+			return nil, fmt.Errorf("reading config file %s: %w", path, err)
+		}
+	case optional && errors.Is(err, os.ErrNotExist):
+	default:
+		return nil, err
+	}
+	if err = c.applyEnv(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Config) applyEnv() error {
+
+	strs := map[string]*string{
+		"LATER_HOST":              &c.Host,
+		"LATER_URL_PATH":          &c.UrlPath,
+		"LATER_AUTH_TOKEN":        &c.AuthToken,
+		"LATER_SHARED_SECRET":     &c.SharedSecret,
+		"LATER_DB":                &c.DBName,
+		"LATER_LOG_LEVEL":         &c.LogLevel,
+		"LATER_LOG_FORMAT":        &c.LogFormat,
+		"LATER_TIMEZONE":          &c.Timezone,
+		"LATER_ADMIN_LISTEN_ADDR": &c.AdminListenAddr,
+	}
+	for k, v := range strs {
+		if e, ok := os.LookupEnv(k); ok {
+			*v = e
+		}
+	}
+	ints := map[string]*int{
+		"LATER_LISTEN_PORT":     &c.ListenPort,
+		"LATER_API_LISTEN_PORT": &c.Api.ListenPort,
+		"LATER_RPC_LISTEN_PORT": &c.Rpc.ListenPort,
+	}
+	for k, v := range ints {
+		if e, ok := os.LookupEnv(k); ok {
+			i, err := strconv.Atoi(e)
+			if err != nil {
+				return fmt.Errorf("%s: %w", k, err)
+			}
+			*v = i
+		}
+	}
+	if e, ok := os.LookupEnv("LATER_POLL_INTERVAL"); ok {
+		d, err := time.ParseDuration(e)
+		if err != nil {
+			return fmt.Errorf("LATER_POLL_INTERVAL: %w", err)
+		}
+		c.PollInterval = Duration(d)
+	}
+	if e, ok := os.LookupEnv("LATER_HISTORY_RETENTION"); ok {
+		d, err := time.ParseDuration(e)
+		if err != nil {
+			return fmt.Errorf("LATER_HISTORY_RETENTION: %w", err)
+		}
+		c.HistoryRetention = Duration(d)
+	}
+	if e, ok := os.LookupEnv("LATER_DELETED_RETENTION"); ok {
+		d, err := time.ParseDuration(e)
+		if err != nil {
+			return fmt.Errorf("LATER_DELETED_RETENTION: %w", err)
+		}
+		c.DeletedRetention = Duration(d)
+	}
+	if e, ok := os.LookupEnv("LATER_RATE_LIMIT"); ok {
+		d, err := time.ParseDuration(e)
+		if err != nil {
+			return fmt.Errorf("LATER_RATE_LIMIT: %w", err)
+		}
+		c.RateLimit = Duration(d)
+	}
+	return nil
+}
+
+// Validate checks the settings needed to run the bot, returning an error
+// describing every problem found.
+func (c *Config) Validate() error {
+
+	var errs []error
+	if c.AuthToken == "" {
+		errs = append(errs, errors.New("authToken is required (or set LATER_AUTH_TOKEN)"))
+	}
+	if c.Host == "" {
+		errs = append(errs, errors.New("host is required (or set LATER_HOST)"))
+	}
+	if c.ListenPort <= 0 || c.ListenPort > 65535 {
+		errs = append(errs, fmt.Errorf("listenPort %d is out of range", c.ListenPort))
+	}
+	if c.DBName == "" {
+		errs = append(errs, errors.New("dbName is required (or set LATER_DB)"))
+	}
+	if _, err := zerolog.ParseLevel(c.LogLevel); err != nil {
+		errs = append(errs, fmt.Errorf("logLevel: %w", err))
+	}
+	if c.LogFormat != "json" && c.LogFormat != "console" {
+		errs = append(errs, fmt.Errorf("logFormat must be 'json' or 'console', got '%s'", c.LogFormat))
+	}
+	if c.PollInterval <= 0 {
+		errs = append(errs, errors.New("pollInterval must be positive"))
+	}
+	if c.Limits.MaxPendingPerOwner < 0 || c.Limits.MaxHorizon < 0 || c.Limits.MaxDataLength < 0 || c.Limits.MaxDescriptionLength < 0 {
+		errs = append(errs, errors.New("limits must not be negative"))
+	}
+	if c.RateLimit < 0 {
+		errs = append(errs, errors.New("rateLimit must not be negative"))
+	}
+	if c.DeletedRetention != 0 && time.Duration(c.DeletedRetention) < app.UndoTTL {
+		errs = append(errs, fmt.Errorf("deletedRetention must be zero or at least %s, so deletions can be undone", app.UndoTTL))
+	}
+	if c.AdminListenAddr != "" {
+		if _, _, err := net.SplitHostPort(c.AdminListenAddr); err != nil {
+			errs = append(errs, fmt.Errorf("adminListenAddr: %w", err))
+		}
+	}
+	if c.Rpc.ListenPort != 0 && len(c.Rpc.Tokens) == 0 {
+		errs = append(errs, errors.New("rpc.tokens is required when rpc.listenPort is set"))
+	}
+	if _, err := time.LoadLocation(c.Timezone); err != nil {
+		errs = append(errs, fmt.Errorf("timezone: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+func (c *Config) Location() (*time.Location, error) {
+	return time.LoadLocation(c.Timezone)
+}
+
+// SetupLogging applies the configured log level and format to the global
+// zerolog logger.
+func (c *Config) SetupLogging() error {
+
+	lvl, err := zerolog.ParseLevel(c.LogLevel)
+	if err != nil {
+		return err
+	}
+	zerolog.SetGlobalLevel(lvl)
+	if c.LogFormat == "console" {
+		log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package config_test
+
+import (
+	"github.com/henges/later/config"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoad(t *testing.T) {
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	err := os.WriteFile(path, []byte(`{"host":"https://example.com","authToken":"from-file","pollInterval":"5s"}`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("LATER_AUTH_TOKEN", "from-env")
+	t.Setenv("LATER_LISTEN_PORT", "8080")
+
+	c, err := config.Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.AuthToken != "from-env" {
+		t.Errorf("Expected env to override file, got '%s'", c.AuthToken)
+	}
+	if c.Host != "https://example.com" {
+		t.Errorf("Expected host from file, got '%s'", c.Host)
+	}
+	if c.ListenPort != 8080 {
+		t.Errorf("Expected listen port from env, got %d", c.ListenPort)
+	}
+	if time.Duration(c.PollInterval) != 5*time.Second {
+		t.Errorf("Expected poll interval from file, got %s", time.Duration(c.PollInterval))
+	}
+	if c.DBName != "file:later.db" {
+		t.Errorf("Expected default db name, got '%s'", c.DBName)
+	}
+	if err = c.Validate(); err != nil {
+		t.Errorf("Expected valid config, got %s", err)
+	}
+}
+
+func TestLoad_MissingExplicitFile(t *testing.T) {
+
+	_, err := config.Load(filepath.Join(t.TempDir(), "nope.json"))
+	if err == nil {
+		t.Error("Expected error for missing config file")
+	}
+}
+
+func TestValidate(t *testing.T) {
+
+	c := config.Default()
+	c.Timezone = "Not/AZone"
+	c.DeletedRetention = config.Duration(time.Minute)
+	c.AdminListenAddr = "nope"
+	c.RateLimit = config.Duration(-time.Second)
+	err := c.Validate()
+	if err == nil {
+		t.Fatal("Expected validation error")
+	}
+	for _, want := range []string{"authToken", "host", "timezone", "deletedRetention", "adminListenAddr", "rateLimit"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected error to mention %s, got: %s", want, err)
+		}
+	}
+}
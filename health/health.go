@@ -0,0 +1,51 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/rs/zerolog/log"
+	"net/http"
+	"time"
+)
+
+// checkTimeout bounds how long all readiness checks together may take.
+const checkTimeout = 5 * time.Second
+
+type Check struct {
+	Name string
+	Func func(ctx context.Context) error
+}
+
+// Handler is satisfied by *http.ServeMux and *bot.WebhookBot.
+type Handler interface {
+	Handle(pattern string, handler http.Handler)
+}
+
+// Register adds /healthz, which succeeds whenever the process is serving,
+// and /readyz, which succeeds only when every check passes.
+func Register(h Handler, checks []Check) {
+	h.Handle("GET /healthz", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("ok\n"))
+	}))
+	h.Handle("GET /readyz", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+		defer cancel()
+
+		status := http.StatusOK
+		results := make(map[string]string, len(checks))
+		for _, c := range checks {
+			if err := c.Func(ctx); err != nil {
+				status = http.StatusServiceUnavailable
+				results[c.Name] = err.Error()
+				continue
+			}
+			results[c.Name] = "ok"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		if err := json.NewEncoder(w).Encode(results); err != nil {
+			log.Err(err).Msg("while writing readiness response")
+		}
+	}))
+}
@@ -0,0 +1,88 @@
+package later
+
+import (
+	"strings"
+	"time"
+)
+
+// WithDeletedRetention has reminders deleted more than d ago purged whenever
+// reminders fire, after which they can't be restored. Deleted reminders are
+// kept forever if d is zero.
+func WithDeletedRetention(d time.Duration) Option {
+	return func(c *cfg) {
+		c.deletedRetention = d
+	}
+}
+
+// RestoreReminders brings back the owner's deleted reminders with the given
+// IDs, and returns how many there were. Reminders that were purged, or that
+// weren't deleted, are skipped. If bringing them back would leave the owner
+// with more pending reminders than the limits allow, none are restored and a
+// TooManyPendingError is returned.
+func (l *Later) RestoreReminders(owner string, ids []int64) (int64, error) {
+	return l.db.RestoreReminders(owner, ids, l.limits.MaxPendingPerOwner)
+}
+
+// PurgeDeleted removes reminders deleted before the given time for good, and
+// returns how many there were.
+func (l *Later) PurgeDeleted(before time.Time) (int64, error) {
+	return l.db.PurgeDeleted(before)
+}
+
+const restoreRemindersSql = `
+UPDATE reminders SET deleted_at = NULL
+WHERE owner = ? AND deleted_at IS NOT NULL AND id IN
+`
+
+// RestoreReminders restores the owner's deleted reminders with the given IDs.
+// If maxPending is positive and restoring them would take the owner past it,
+// it restores none and returns a TooManyPendingError.
+func (db *DB) RestoreReminders(owner string, ids []int64, maxPending int) (int64, error) {
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	args := []any{owner}
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	query := restoreRemindersSql + "(?" + strings.Repeat(", ?", len(ids)-1) + ");"
+	res, err := tx.Exec(query, args...)
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if maxPending > 0 {
+		// Counting after the update includes what it restored, and the
+		// update has already locked out other writers.
+		var pending int
+		if err = tx.QueryRow(countRemindersByOwnerSql, owner).Scan(&pending); err != nil {
+			return 0, err
+		}
+		if n > 0 && pending > maxPending {
+			return 0, &TooManyPendingError{maxPending}
+		}
+	}
+	return n, tx.Commit()
+}
+
+const purgeDeletedSql = `
+DELETE FROM reminders WHERE deleted_at < $1;
+`
+
+func (db *DB) PurgeDeleted(before time.Time) (int64, error) {
+
+	res, err := db.conn.Exec(purgeDeletedSql, before.Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
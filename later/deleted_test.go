@@ -0,0 +1,221 @@
+package later_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"github.com/henges/later/later"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLater_SoftDelete(t *testing.T) {
+
+	l, err := later.NewLater(later.WithDeletedRetention(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Truncate(time.Second)
+	var ids []int64
+	for _, data := range []string{`{"name":"water the plants"}`, `{"name":"call mum"}`} {
+		id, err := l.InsertReminder(later.Reminder{Owner: "alex", FireTime: now.Add(time.Minute), CallbackData: data})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	deleted, err := l.DeleteReminderWithOwner("alex", ids[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !deleted {
+		t.Fatal("expected the reminder to be deleted")
+	}
+	if deleted, _ = l.DeleteReminderWithOwner("alex", ids[0]); deleted {
+		t.Error("deleted the same reminder twice")
+	}
+
+	// Deleted reminders aren't found by anything.
+	visible := func() []int64 {
+		var ret []int64
+		rs, err := l.GetRemindersByOwner("alex")
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range rs {
+			ret = append(ret, r.ID)
+		}
+		return ret
+	}
+	if res := visible(); !slices.Equal(res, ids[1:]) {
+		t.Errorf("expected only %v to be left, got %v", ids[1:], res)
+	}
+	if n, _ := l.CountReminders(); n != 1 {
+		t.Errorf("expected 1 reminder counted, got %d", n)
+	}
+	if _, found, _ := l.GetReminderWithOwner("alex", ids[0]); found {
+		t.Error("got a deleted reminder")
+	}
+	page, err := l.ListReminders(context.Background(), later.Query{Owner: "alex", Search: "plants"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Reminders) != 0 {
+		t.Errorf("search found a deleted reminder: %v", page.Reminders)
+	}
+
+	// Only the owner can restore them.
+	if n, _ := l.RestoreReminders("sam", ids); n != 0 {
+		t.Errorf("sam restored %d of alex's reminders", n)
+	}
+	n, err := l.RestoreReminders("alex", ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("expected 1 reminder restored, got %d", n)
+	}
+	if res := visible(); !slices.Equal(res, ids) {
+		t.Errorf("expected %v after restoring, got %v", ids, res)
+	}
+	page, err = l.ListReminders(context.Background(), later.Query{Owner: "alex", Search: "plants"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Reminders) != 1 {
+		t.Errorf("expected search to find the restored reminder, got %v", page.Reminders)
+	}
+
+	// Deleted reminders don't fire, and are purged after the retention
+	// period.
+	if _, err = l.DeleteReminderWithOwner("alex", ids[1]); err != nil {
+		t.Fatal(err)
+	}
+	var fired []later.SavedReminder
+	l.SetCallback(func(r later.SavedReminder) error {
+		fired = append(fired, r)
+		return nil
+	})
+	if err = l.FireDueReminders(now.Add(2 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if len(fired) != 1 || fired[0].ID != ids[0] {
+		t.Errorf("expected only %d to fire, got %v", ids[0], fired)
+	}
+	if n, _ = l.RestoreReminders("alex", ids[1:]); n != 0 {
+		t.Error("restored a purged reminder")
+	}
+}
+
+func TestLater_RestoreRespectsLimits(t *testing.T) {
+
+	l, err := later.NewLater(later.WithLimits(later.Limits{MaxPendingPerOwner: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for range 2 {
+		id, err := l.InsertReminder(later.Reminder{Owner: "alex", FireTime: time.Now().Add(time.Hour), CallbackData: "{}"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	if _, err = l.DeleteRemindersWithOwner("alex", ids); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = l.InsertReminder(later.Reminder{Owner: "alex", FireTime: time.Now().Add(time.Hour), CallbackData: "{}"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var tooMany *later.TooManyPendingError
+	if _, err = l.RestoreReminders("alex", ids); !errors.As(err, &tooMany) {
+		t.Fatalf("expected TooManyPendingError, got %v", err)
+	}
+	if n, _ := l.CountRemindersByOwner("alex"); n != 1 {
+		t.Errorf("expected nothing to be restored, got %d pending", n)
+	}
+	if n, err := l.RestoreReminders("alex", ids[:1]); err != nil || n != 1 {
+		t.Errorf("expected 1 restored, got %d, %v", n, err)
+	}
+}
+
+func TestLater_MigrateDeletedAt(t *testing.T) {
+
+	name := "file:" + filepath.Join(t.TempDir(), "later.db")
+	conn, err := sql.Open("sqlite3", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Exec(`
+CREATE TABLE reminders (
+    id integer primary key,
+    owner text not null,
+    fire_time int not null,
+    callback_data text not null
+);
+INSERT INTO reminders(owner, fire_time, callback_data) VALUES ('alex', 0, 'hello');
+`)
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := later.NewLater(later.WithDBName(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = l.DeleteReminderWithOwner("alex", 1); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := l.RestoreReminders("alex", []int64{1}); n != 1 {
+		t.Errorf("expected the old reminder to be restored, got %d", n)
+	}
+}
+
+func TestLater_DeleteRemindersWithOwner(t *testing.T) {
+
+	l, err := later.NewLater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fireTime := time.Now().Add(time.Hour)
+	for _, owner := range []string{"alex", "alex", "alex", "sam"} {
+		if _, err = l.InsertReminder(later.Reminder{Owner: owner, FireTime: fireTime, CallbackData: "hello"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Only alex's reminders that exist are deleted, once each.
+	deleted, err := l.DeleteRemindersWithOwner("alex", []int64{1, 3, 4, 99, 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(deleted, []int64{1, 3}) {
+		t.Errorf("expected 1 and 3 to be deleted, got %v", deleted)
+	}
+	if n, _ := l.CountReminders(); n != 2 {
+		t.Errorf("expected 2 reminders left, got %d", n)
+	}
+}
+
+func TestLater_DeleteReminderWithOwnerError(t *testing.T) {
+
+	name := "file:" + filepath.Join(t.TempDir(), "later.db")
+	l, err := later.NewLater(later.WithDBName(name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn, err := sql.Open("sqlite3", name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = conn.Exec(`DROP TABLE reminders;`)
+	conn.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = l.DeleteReminderWithOwner("alex", 1); err == nil {
+		t.Error("expected an error with the table gone")
+	}
+}
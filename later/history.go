@@ -0,0 +1,186 @@
+package later
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// DeliveryStatus is whether a fired reminder's Callback succeeded.
+type DeliveryStatus string
+
+const (
+	Delivered DeliveryStatus = "delivered"
+	Failed    DeliveryStatus = "failed"
+)
+
+// HistoryEntry is a reminder that has fired.
+type HistoryEntry struct {
+	ID int64 `json:"id"`
+	// ReminderID is the ID the reminder had while it was pending.
+	ReminderID   int64          `json:"reminderId"`
+	Owner        string         `json:"owner"`
+	FireTime     time.Time      `json:"fireTime"`
+	CallbackData string         `json:"callbackData"`
+	DeliveredAt  time.Time      `json:"deliveredAt"`
+	Status       DeliveryStatus `json:"status"`
+	// Error is why delivery failed, if it did.
+	Error string `json:"error,omitempty"`
+	// AcknowledgedAt is when the owner marked the reminder done, or the zero
+	// time if they haven't.
+	AcknowledgedAt time.Time `json:"acknowledgedAt"`
+}
+
+// HistoryQuery selects history entries for ListHistory. Zero fields don't
+// filter.
+type HistoryQuery struct {
+	Owner string
+	// Search works like Query.Search.
+	Search string
+	// Limit is the most entries to return, defaulting to DefaultQueryLimit.
+	// The most recently delivered are returned first.
+	Limit int
+}
+
+// WithHistoryRetention has history older than d pruned whenever reminders
+// fire. History is kept forever if d is zero.
+func WithHistoryRetention(d time.Duration) Option {
+	return func(c *cfg) {
+		c.historyRetention = d
+	}
+}
+
+// ListHistory returns the fired reminders matching q.
+func (l *Later) ListHistory(ctx context.Context, q HistoryQuery) ([]HistoryEntry, error) {
+	return l.db.ListHistory(ctx, q)
+}
+
+// AcknowledgeHistory marks the owner's reminder with the given ID, that was
+// due at fireTime, as done. It returns whether there was such a reminder that
+// hadn't already been acknowledged.
+func (l *Later) AcknowledgeHistory(owner string, reminderID int64, fireTime, at time.Time) (bool, error) {
+	return l.db.AcknowledgeHistory(owner, reminderID, fireTime, at)
+}
+
+// PruneHistory deletes history delivered before the given time, and returns
+// how many entries there were.
+func (l *Later) PruneHistory(before time.Time) (int64, error) {
+	return l.db.PruneHistory(before)
+}
+
+const archiveReminderSql = `
+INSERT INTO history(reminder_id, owner, fire_time, callback_data, delivered_at, status, error)
+VALUES ($1, $2, $3, $4, $5, $6, $7);
+`
+
+// ArchiveReminder moves a fired reminder into history.
+func (db *DB) ArchiveReminder(r SavedReminder, deliveredAt time.Time, deliveryErr error) error {
+
+	status, errText := Delivered, ""
+	if deliveryErr != nil {
+		status, errText = Failed, deliveryErr.Error()
+	}
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	_, err = tx.Exec(archiveReminderSql, r.ID, r.Owner, r.FireTime.Unix(), r.CallbackData, deliveredAt.Unix(), status, errText)
+	if err != nil {
+		return err
+	}
+	if _, err = tx.Exec(deleteReminderSql, r.ID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+const listHistorySql = `
+SELECT id, reminder_id, owner, fire_time, callback_data, delivered_at, status, error, acknowledged_at
+FROM history
+`
+
+func (db *DB) ListHistory(ctx context.Context, q HistoryQuery) ([]HistoryEntry, error) {
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	limit = min(limit, MaxQueryLimit)
+
+	var where []string
+	var args []any
+	if q.Owner != "" {
+		where = append(where, "owner = ?")
+		args = append(args, q.Owner)
+	}
+	if terms := searchTerms(q.Search); terms != "" {
+		where = append(where, "id IN (SELECT rowid FROM history_fts WHERE history_fts MATCH ?)")
+		args = append(args, terms)
+	}
+	var sb strings.Builder
+	sb.WriteString(listHistorySql)
+	if len(where) > 0 {
+		sb.WriteString("WHERE " + strings.Join(where, " AND ") + "\n")
+	}
+	sb.WriteString("ORDER BY delivered_at DESC, id DESC\nLIMIT ?;")
+	args = append(args, limit)
+
+	rows, err := db.conn.QueryContext(ctx, sb.String(), args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ret []HistoryEntry
+	for rows.Next() {
+		e := HistoryEntry{}
+		var fireTime, deliveredAt int64
+		var ackedAt sql.NullInt64
+		err = rows.Scan(&e.ID, &e.ReminderID, &e.Owner, &fireTime, &e.CallbackData, &deliveredAt, &e.Status, &e.Error, &ackedAt)
+		if err != nil {
+			return nil, err
+		}
+		e.FireTime = time.Unix(fireTime, 0)
+		e.DeliveredAt = time.Unix(deliveredAt, 0)
+		if ackedAt.Valid {
+			e.AcknowledgedAt = time.Unix(ackedAt.Int64, 0)
+		}
+		ret = append(ret, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+const acknowledgeHistorySql = `
+UPDATE history SET acknowledged_at = $1
+WHERE owner = $2 AND reminder_id = $3 AND fire_time = $4 AND acknowledged_at IS NULL;
+`
+
+func (db *DB) AcknowledgeHistory(owner string, reminderID int64, fireTime, at time.Time) (bool, error) {
+
+	res, err := db.conn.Exec(acknowledgeHistorySql, at.Unix(), owner, reminderID, fireTime.Unix())
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return affected > 0, nil
+}
+
+const pruneHistorySql = `
+DELETE FROM history WHERE delivered_at < $1;
+`
+
+func (db *DB) PruneHistory(before time.Time) (int64, error) {
+
+	res, err := db.conn.Exec(pruneHistorySql, before.Unix())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,90 @@
+package later_test
+
+import (
+	"context"
+	"errors"
+	"github.com/henges/later/later"
+	"testing"
+	"time"
+)
+
+func TestLater_History(t *testing.T) {
+
+	l, err := later.NewLater(later.WithHistoryRetention(24 * time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Truncate(time.Second)
+	for _, data := range []string{`{"name":"water the plants"}`, `{"name":"call mum"}`} {
+		_, err = l.InsertReminder(later.Reminder{Owner: "alex", FireTime: now.Add(-time.Minute), CallbackData: data})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.SetCallback(func(r later.SavedReminder) error {
+		if r.CallbackData == `{"name":"call mum"}` {
+			return errors.New("chat not found")
+		}
+		return nil
+	})
+	if err = l.FireDueReminders(now); err != nil {
+		t.Fatal(err)
+	}
+
+	hist, err := l.ListHistory(context.Background(), later.HistoryQuery{Owner: "alex"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hist) != 2 {
+		t.Fatalf("expected 2 history entries, got %d", len(hist))
+	}
+	statuses := map[string]later.DeliveryStatus{}
+	for _, e := range hist {
+		statuses[e.CallbackData] = e.Status
+		if !e.DeliveredAt.Equal(now) {
+			t.Errorf("expected %v to be delivered at %v, got %v", e.CallbackData, now, e.DeliveredAt)
+		}
+	}
+	if statuses[`{"name":"water the plants"}`] != later.Delivered || statuses[`{"name":"call mum"}`] != later.Failed {
+		t.Errorf("wrong delivery statuses: %v", statuses)
+	}
+
+	found, err := l.ListHistory(context.Background(), later.HistoryQuery{Owner: "alex", Search: "plant"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0].CallbackData != `{"name":"water the plants"}` {
+		t.Errorf("expected to find the plants reminder, got %v", found)
+	}
+
+	e := found[0]
+	acked, err := l.AcknowledgeHistory("sam", e.ReminderID, e.FireTime, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if acked {
+		t.Error("someone else acknowledged alex's reminder")
+	}
+	for i, expected := range []bool{true, false} {
+		acked, err = l.AcknowledgeHistory("alex", e.ReminderID, e.FireTime, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if acked != expected {
+			t.Errorf("acknowledgement %d: expected %v, got %v", i, expected, acked)
+		}
+	}
+
+	// History older than the retention period goes the next time reminders
+	// fire.
+	if err = l.FireDueReminders(now.Add(25 * time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	hist, err = l.ListHistory(context.Background(), later.HistoryQuery{Owner: "alex"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hist) != 0 {
+		t.Errorf("expected history to be pruned, got %v", hist)
+	}
+}
@@ -4,35 +4,67 @@ import (
 	"context"
 	"database/sql"
 	_ "embed"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"github.com/henges/later/metrics"
 	_ "github.com/ncruces/go-sqlite3/driver"
 	_ "github.com/ncruces/go-sqlite3/embed"
 	"github.com/rs/zerolog/log"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
 type Reminder struct {
-	Owner        string
-	FireTime     time.Time
-	CallbackData string
+	Owner        string    `json:"owner"`
+	FireTime     time.Time `json:"fireTime"`
+	CallbackData string    `json:"callbackData"`
+	// Tags are labels the reminder can be queried and deleted by. They're
+	// matched exactly, and returned sorted.
+	Tags []string `json:"tags,omitempty"`
 }
 
 type SavedReminder struct {
-	ID int64
+	ID int64 `json:"id"`
 	Reminder
 }
 
-type Callback func(reminder Reminder)
+// Callback delivers a fired reminder. The error it returns, if any, is
+// recorded in the reminder's history as the reason delivery failed.
+type Callback func(reminder SavedReminder) error
+
+// Subscriber is told about every fired reminder, after the Callback. Firing
+// waits for it to return.
+type Subscriber func(reminder SavedReminder)
+
+// HoldFunc decides whether a due reminder should be held back rather than
+// fired, and if so until when.
+type HoldFunc func(reminder Reminder, now time.Time) (until time.Time, held bool)
 
 type Later struct {
 	db          *DB
 	cb          Callback
+	hold        HoldFunc
 	stopPolling func()
+	lastPoll    atomic.Int64
+	limits      Limits
+	// historyRetention and deletedRetention are how long fired and deleted
+	// reminders are kept. Zero keeps them forever.
+	historyRetention time.Duration
+	deletedRetention time.Duration
+
+	subsMu  sync.Mutex
+	subs    map[int]Subscriber
+	nextSub int
 }
 
 type cfg struct {
-	dbName string
+	dbName           string
+	limits           Limits
+	historyRetention time.Duration
+	deletedRetention time.Duration
 }
 
 func WithDBName(name string) Option {
@@ -59,7 +91,64 @@ func NewLater(opts ...Option) (*Later, error) {
 	if err = db.EnsureMigrated(); err != nil {
 		return nil, err
 	}
-	return &Later{db, nil, nil}, nil
+	return &Later{
+		db:               db,
+		limits:           conf.limits,
+		historyRetention: conf.historyRetention,
+		deletedRetention: conf.deletedRetention,
+	}, nil
+}
+
+// Subscribe registers a callback that is invoked for every fired reminder,
+// in addition to the callback given to StartPoll. The returned function
+// removes the subscription.
+func (l *Later) Subscribe(cb Subscriber) func() {
+
+	l.subsMu.Lock()
+	defer l.subsMu.Unlock()
+	if l.subs == nil {
+		l.subs = make(map[int]Subscriber)
+	}
+	id := l.nextSub
+	l.nextSub++
+	l.subs[id] = cb
+
+	return func() {
+		l.subsMu.Lock()
+		defer l.subsMu.Unlock()
+		delete(l.subs, id)
+	}
+}
+
+func (l *Later) notify(r SavedReminder) error {
+
+	var err error
+	if l.cb != nil {
+		err = l.cb(r)
+	}
+	// Subscribers may block, so they're called without the lock held.
+	l.subsMu.Lock()
+	subs := make([]Subscriber, 0, len(l.subs))
+	for _, cb := range l.subs {
+		subs = append(subs, cb)
+	}
+	l.subsMu.Unlock()
+	for _, cb := range subs {
+		cb(r)
+	}
+	return err
+}
+
+// SetCallback sets the callback invoked by FireDueReminders without starting
+// the poller.
+func (l *Later) SetCallback(callback Callback) {
+	l.cb = callback
+}
+
+// SetHold sets the function FireDueReminders asks before firing each
+// reminder. Held reminders are moved to the time it returns instead.
+func (l *Later) SetHold(hold HoldFunc) {
+	l.hold = hold
 }
 
 func (l *Later) StartPoll(callback Callback, dur time.Duration) error {
@@ -119,29 +208,156 @@ func (l *Later) FireDueReminders(now time.Time) error {
 		return err
 	}
 	for _, r := range reminders {
-		if l.cb != nil {
-			l.cb(r.Reminder)
+		if l.hold != nil {
+			if until, held := l.hold(r.Reminder, now); held && until.After(now) {
+				_, err = l.db.RescheduleReminder(r.ID, until)
+				if err != nil {
+					return err
+				}
+				continue
+			}
 		}
-		_, err = l.db.DeleteReminder(r.ID)
+		deliveryErr := l.notify(r)
+		metrics.RemindersFired.Inc()
+		metrics.FireLatency.Observe(now.Sub(r.FireTime).Seconds())
+		err = l.db.ArchiveReminder(r, now, deliveryErr)
 		if err != nil {
 			return err
 		}
 	}
+	if l.historyRetention > 0 {
+		if _, err = l.db.PruneHistory(now.Add(-l.historyRetention)); err != nil {
+			return err
+		}
+	}
+	if l.deletedRetention > 0 {
+		if _, err = l.db.PurgeDeleted(now.Add(-l.deletedRetention)); err != nil {
+			return err
+		}
+	}
+	l.lastPoll.Store(now.Unix())
 	return nil
 }
+
+// LastPoll returns the time FireDueReminders last completed successfully, or
+// the zero time if it never has.
+func (l *Later) LastPoll() time.Time {
+	ts := l.lastPoll.Load()
+	if ts == 0 {
+		return time.Time{}
+	}
+	return time.Unix(ts, 0)
+}
+
+func (l *Later) Ping(ctx context.Context) error {
+	return l.db.conn.PingContext(ctx)
+}
+
+func (l *Later) CountReminders() (int, error) {
+	return l.db.CountReminders()
+}
+
+// DeleteReminderWithOwner deletes the owner's reminder with the given ID,
+// which can be brought back with RestoreReminders until it's purged.
 func (l *Later) DeleteReminderWithOwner(owner string, id int64) (bool, error) {
 
-	return l.db.DeleteReminderWithOwner(owner, id)
+	return l.db.DeleteReminderWithOwner(owner, id, time.Now())
 }
 
-func (l *Later) InsertReminder(r Reminder) error {
-	return l.db.InsertReminder(r)
+// DeleteRemindersWithOwner deletes the owner's reminders with the given IDs
+// like DeleteReminderWithOwner, all at once, and returns the IDs of those that
+// existed.
+func (l *Later) DeleteRemindersWithOwner(owner string, ids []int64) ([]int64, error) {
+	return l.db.DeleteRemindersWithOwner(owner, ids, time.Now())
+}
+
+// DeleteRemindersWithOwnerAndTag deletes all of owner's reminders with the
+// tag like DeleteReminderWithOwner, and returns their IDs.
+func (l *Later) DeleteRemindersWithOwnerAndTag(owner, tag string) ([]int64, error) {
+	return l.db.DeleteRemindersWithOwnerAndTag(owner, tag, time.Now())
+}
+
+func (l *Later) InsertReminder(r Reminder) (int64, error) {
+	if err := l.limits.checkReminder(r, time.Now()); err != nil {
+		return 0, err
+	}
+	id, err := l.db.InsertReminder(r, l.limits.MaxPendingPerOwner)
+	if err != nil {
+		return 0, err
+	}
+	metrics.RemindersCreated.Inc()
+	return id, nil
+}
+
+// InsertReminders inserts all of rs, in one transaction, or none of them if
+// any breaks the limits or can't be inserted.
+func (l *Later) InsertReminders(rs []Reminder) ([]int64, error) {
+	now := time.Now()
+	for i, r := range rs {
+		if err := l.limits.checkReminder(r, now); err != nil {
+			return nil, fmt.Errorf("reminder %d: %w", i, err)
+		}
+	}
+	ids, err := l.db.InsertReminders(rs, l.limits.MaxPendingPerOwner)
+	if err != nil {
+		return nil, err
+	}
+	metrics.RemindersCreated.Add(float64(len(ids)))
+	return ids, nil
 }
 
 func (l *Later) GetRemindersByOwner(owner string) ([]SavedReminder, error) {
 	return l.db.GetRemindersByOwner(owner)
 }
 
+func (l *Later) CountRemindersByOwner(owner string) (int, error) {
+	return l.db.CountRemindersByOwner(owner)
+}
+
+func (l *Later) GetAllReminders() ([]SavedReminder, error) {
+	return l.db.GetAllReminders()
+}
+
+func (l *Later) GetRemindersDueAt(now time.Time) ([]SavedReminder, error) {
+	return l.db.GetRemindersDueAt(now)
+}
+
+func (l *Later) GetReminderWithOwner(owner string, id int64) (SavedReminder, bool, error) {
+	return l.db.GetReminderWithOwner(owner, id)
+}
+
+func (l *Later) UpdateReminderWithOwner(owner string, id int64, r Reminder) (bool, error) {
+	if err := l.limits.checkReminder(r, time.Now()); err != nil {
+		return false, err
+	}
+	return l.db.UpdateReminderWithOwner(owner, id, r)
+}
+
+// SetAccess records whether the subject of the given kind (such as a user or
+// chat) is allowed to use the bot.
+func (l *Later) SetAccess(kind string, id int64, allowed bool) error {
+	return l.db.SetAccess(kind, id, allowed)
+}
+
+// GetAccess returns whether the subject is allowed, and whether any rule was
+// recorded for it at all.
+func (l *Later) GetAccess(kind string, id int64) (allowed bool, found bool, err error) {
+	return l.db.GetAccess(kind, id)
+}
+
+// GetSetting returns the value of an owner's setting, and whether it was set.
+func (l *Later) GetSetting(owner, key string) (string, bool, error) {
+	return l.db.GetSetting(owner, key)
+}
+
+func (l *Later) SetSetting(owner, key, value string) error {
+	return l.db.SetSetting(owner, key, value)
+}
+
+func (l *Later) DeleteSetting(owner, key string) error {
+	return l.db.DeleteSetting(owner, key)
+}
+
 type DB struct {
 	conn *sql.DB
 }
@@ -149,9 +365,41 @@ type DB struct {
 //go:embed schema.sql
 var schema string
 
+const reminderColumnsSql = `
+SELECT name FROM pragma_table_info('reminders');
+`
+
+const addDeletedAtSql = `
+ALTER TABLE reminders ADD COLUMN deleted_at int;
+`
+
 func (db *DB) EnsureMigrated() error {
 
-	_, err := db.conn.Exec(schema)
+	// Tables made before soft deletion need the column before the schema's
+	// indexes and triggers can refer to it.
+	rows, err := db.conn.Query(reminderColumnsSql)
+	if err != nil {
+		return err
+	}
+	var cols []string
+	for rows.Next() {
+		var name string
+		if err = rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		cols = append(cols, name)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if len(cols) > 0 && !slices.Contains(cols, "deleted_at") {
+		if _, err = db.conn.Exec(addDeletedAtSql); err != nil {
+			return err
+		}
+	}
+	_, err = db.conn.Exec(schema)
 	return err
 }
 
@@ -160,15 +408,113 @@ INSERT INTO reminders(owner, fire_time, callback_data)
 VALUES ($1, $2, $3);
 `
 
-func (db *DB) InsertReminder(r Reminder) error {
+// insertReminderIfUnderSql counts the owner's pending reminders in the same
+// statement as the insert, so concurrent inserts can't both squeeze under the
+// limit.
+const insertReminderIfUnderSql = `
+INSERT INTO reminders(owner, fire_time, callback_data)
+SELECT $1, $2, $3
+WHERE (SELECT count(*) FROM reminders WHERE owner = $1 AND deleted_at IS NULL) < $4;
+`
+
+// InsertReminder saves r and returns its ID. If maxPending is positive and
+// the owner already has that many pending reminders, it returns a
+// TooManyPendingError instead.
+func (db *DB) InsertReminder(r Reminder, maxPending int) (int64, error) {
 
-	_, err := db.conn.Exec(insertReminderSql, r.Owner, r.FireTime.Unix(), r.CallbackData)
-	return err
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	id, err := insertReminder(tx, r, maxPending)
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// InsertReminders inserts all of rs or, if any can't be, none of them.
+func (db *DB) InsertReminders(rs []Reminder, maxPending int) ([]int64, error) {
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	ids := make([]int64, 0, len(rs))
+	for i, r := range rs {
+		id, err := insertReminder(tx, r, maxPending)
+		if err != nil {
+			return nil, fmt.Errorf("reminder %d: %w", i, err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, tx.Commit()
+}
+
+func insertReminder(tx *sql.Tx, r Reminder, maxPending int) (int64, error) {
+
+	var res sql.Result
+	var err error
+	if maxPending > 0 {
+		res, err = tx.Exec(insertReminderIfUnderSql, r.Owner, r.FireTime.Unix(), r.CallbackData, maxPending)
+	} else {
+		res, err = tx.Exec(insertReminderSql, r.Owner, r.FireTime.Unix(), r.CallbackData)
+	}
+	if err != nil {
+		return 0, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, &TooManyPendingError{maxPending}
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	if err = insertTags(tx, id, r.Tags); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+const insertTagSql = `
+INSERT OR IGNORE INTO reminder_tags(reminder_id, tag)
+VALUES ($1, $2);
+`
+
+func insertTags(tx *sql.Tx, id int64, tags []string) error {
+
+	for _, tag := range tags {
+		if _, err := tx.Exec(insertTagSql, id, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// decodeTags decodes the JSON array of tags selected with a reminder.
+func decodeTags(s string) ([]string, error) {
+
+	var tags []string
+	if err := json.Unmarshal([]byte(s), &tags); err != nil {
+		return nil, err
+	}
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	return tags, nil
 }
 
 const getRemindersDueAtSql = `
-SELECT id, owner, fire_time, callback_data FROM reminders
-WHERE fire_time <= $1;
+SELECT id, owner, fire_time, callback_data,
+    (SELECT json_group_array(tag) FROM reminder_tags WHERE reminder_id = reminders.id)
+FROM reminders
+WHERE fire_time <= $1 AND deleted_at IS NULL;
 `
 
 func (db *DB) GetRemindersDueAt(when time.Time) ([]SavedReminder, error) {
@@ -182,11 +528,15 @@ func (db *DB) GetRemindersDueAt(when time.Time) ([]SavedReminder, error) {
 	for rows.Next() {
 		e := SavedReminder{}
 		var ts int64
-		err = rows.Scan(&e.ID, &e.Owner, &ts, &e.CallbackData)
+		var tags string
+		err = rows.Scan(&e.ID, &e.Owner, &ts, &e.CallbackData, &tags)
 		if err != nil {
 			return nil, err
 		}
 		e.FireTime = time.Unix(ts, 0)
+		if e.Tags, err = decodeTags(tags); err != nil {
+			return nil, err
+		}
 		ret = append(ret, e)
 	}
 	if err = rows.Err(); err != nil {
@@ -196,8 +546,10 @@ func (db *DB) GetRemindersDueAt(when time.Time) ([]SavedReminder, error) {
 }
 
 const getRemindersByOwnerSql = `
-SELECT id, owner, fire_time, callback_data FROM reminders
-WHERE owner = $1;
+SELECT id, owner, fire_time, callback_data,
+    (SELECT json_group_array(tag) FROM reminder_tags WHERE reminder_id = reminders.id)
+FROM reminders
+WHERE owner = $1 AND deleted_at IS NULL;
 `
 
 func (db *DB) GetRemindersByOwner(owner string) ([]SavedReminder, error) {
@@ -210,11 +562,72 @@ func (db *DB) GetRemindersByOwner(owner string) ([]SavedReminder, error) {
 	for rows.Next() {
 		e := SavedReminder{}
 		var ts int64
-		err = rows.Scan(&e.ID, &e.Owner, &ts, &e.CallbackData)
+		var tags string
+		err = rows.Scan(&e.ID, &e.Owner, &ts, &e.CallbackData, &tags)
+		if err != nil {
+			return nil, err
+		}
+		e.FireTime = time.Unix(ts, 0)
+		if e.Tags, err = decodeTags(tags); err != nil {
+			return nil, err
+		}
+		ret = append(ret, e)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+const countRemindersSql = `
+SELECT count(*) FROM reminders WHERE deleted_at IS NULL;
+`
+
+func (db *DB) CountReminders() (int, error) {
+
+	var n int
+	err := db.conn.QueryRow(countRemindersSql).Scan(&n)
+	return n, err
+}
+
+const countRemindersByOwnerSql = `
+SELECT count(*) FROM reminders WHERE owner = $1 AND deleted_at IS NULL;
+`
+
+func (db *DB) CountRemindersByOwner(owner string) (int, error) {
+
+	var n int
+	err := db.conn.QueryRow(countRemindersByOwnerSql, owner).Scan(&n)
+	return n, err
+}
+
+const getAllRemindersSql = `
+SELECT id, owner, fire_time, callback_data,
+    (SELECT json_group_array(tag) FROM reminder_tags WHERE reminder_id = reminders.id)
+FROM reminders
+WHERE deleted_at IS NULL
+ORDER BY id;
+`
+
+func (db *DB) GetAllReminders() ([]SavedReminder, error) {
+
+	rows, err := db.conn.Query(getAllRemindersSql)
+	if err != nil {
+		return nil, err
+	}
+	var ret []SavedReminder
+	for rows.Next() {
+		e := SavedReminder{}
+		var ts int64
+		var tags string
+		err = rows.Scan(&e.ID, &e.Owner, &ts, &e.CallbackData, &tags)
 		if err != nil {
 			return nil, err
 		}
 		e.FireTime = time.Unix(ts, 0)
+		if e.Tags, err = decodeTags(tags); err != nil {
+			return nil, err
+		}
 		ret = append(ret, e)
 	}
 	if err = rows.Err(); err != nil {
@@ -223,6 +636,87 @@ func (db *DB) GetRemindersByOwner(owner string) ([]SavedReminder, error) {
 	return ret, nil
 }
 
+const getReminderWithOwnerSql = `
+SELECT id, owner, fire_time, callback_data,
+    (SELECT json_group_array(tag) FROM reminder_tags WHERE reminder_id = reminders.id)
+FROM reminders
+WHERE owner = $1 AND id = $2 AND deleted_at IS NULL;
+`
+
+func (db *DB) GetReminderWithOwner(owner string, id int64) (SavedReminder, bool, error) {
+
+	e := SavedReminder{}
+	var ts int64
+	var tags string
+	err := db.conn.QueryRow(getReminderWithOwnerSql, owner, id).Scan(&e.ID, &e.Owner, &ts, &e.CallbackData, &tags)
+	if errors.Is(err, sql.ErrNoRows) {
+		return SavedReminder{}, false, nil
+	}
+	if err != nil {
+		return SavedReminder{}, false, err
+	}
+	e.FireTime = time.Unix(ts, 0)
+	if e.Tags, err = decodeTags(tags); err != nil {
+		return SavedReminder{}, false, err
+	}
+	return e, true, nil
+}
+
+const updateReminderWithOwnerSql = `
+UPDATE reminders SET fire_time = $1, callback_data = $2
+WHERE owner = $3 AND id = $4 AND deleted_at IS NULL;
+`
+
+const deleteTagsSql = `
+DELETE FROM reminder_tags WHERE reminder_id = $1;
+`
+
+func (db *DB) UpdateReminderWithOwner(owner string, id int64, r Reminder) (bool, error) {
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+	res, err := tx.Exec(updateReminderWithOwnerSql, r.FireTime.Unix(), r.CallbackData, owner, id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affected != 1 {
+		return false, nil
+	}
+	if _, err = tx.Exec(deleteTagsSql, id); err != nil {
+		return false, err
+	}
+	if err = insertTags(tx, id, r.Tags); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+const rescheduleReminderSql = `
+UPDATE reminders SET fire_time = $1 WHERE id = $2;
+`
+
+func (db *DB) RescheduleReminder(id int64, fireTime time.Time) (bool, error) {
+
+	res, err := db.conn.Exec(rescheduleReminderSql, fireTime.Unix(), id)
+	if err != nil {
+		return false, err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return affected == 1, nil
+}
+
 const deleteReminderSql = `
 DELETE FROM reminders WHERE id = $1;
 `
@@ -230,25 +724,156 @@ DELETE FROM reminders WHERE id = $1;
 func (db *DB) DeleteReminder(id int64) (bool, error) {
 
 	res, err := db.conn.Exec(deleteReminderSql, id)
+	if err != nil {
+		return false, err
+	}
 	affected, err := res.RowsAffected()
 	if err != nil {
 		return false, err
 	}
 
-	return affected == 1, err
+	return affected == 1, nil
 }
 
 const deleteReminderWithOwnerSql = `
-DELETE FROM reminders WHERE owner = $1 and id = $2;
+UPDATE reminders SET deleted_at = $1 WHERE owner = $2 AND id = $3 AND deleted_at IS NULL;
 `
 
-func (db *DB) DeleteReminderWithOwner(owner string, id int64) (bool, error) {
+// DeleteReminderWithOwner marks the reminder deleted as of at. It stays in
+// the table, ignored by everything else, until PurgeDeleted removes it.
+func (db *DB) DeleteReminderWithOwner(owner string, id int64, at time.Time) (bool, error) {
 
-	res, err := db.conn.Exec(deleteReminderWithOwnerSql, owner, id)
+	res, err := db.conn.Exec(deleteReminderWithOwnerSql, at.Unix(), owner, id)
+	if err != nil {
+		return false, err
+	}
 	affected, err := res.RowsAffected()
 	if err != nil {
 		return false, err
 	}
 
-	return affected == 1, err
+	return affected == 1, nil
+}
+
+func (db *DB) DeleteRemindersWithOwner(owner string, ids []int64, at time.Time) ([]int64, error) {
+
+	tx, err := db.conn.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	var deleted []int64
+	for _, id := range ids {
+		res, err := tx.Exec(deleteReminderWithOwnerSql, at.Unix(), owner, id)
+		if err != nil {
+			return nil, err
+		}
+		affected, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if affected == 1 {
+			deleted = append(deleted, id)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return deleted, nil
+}
+
+const deleteRemindersWithOwnerAndTagSql = `
+UPDATE reminders SET deleted_at = $1
+WHERE owner = $2 AND deleted_at IS NULL AND id IN (
+    SELECT reminder_id FROM reminder_tags WHERE tag = $3
+)
+RETURNING id;
+`
+
+// DeleteRemindersWithOwnerAndTag marks all of owner's reminders with the tag
+// deleted as of at, and returns their IDs.
+func (db *DB) DeleteRemindersWithOwnerAndTag(owner, tag string, at time.Time) ([]int64, error) {
+
+	rows, err := db.conn.Query(deleteRemindersWithOwnerAndTagSql, at.Unix(), owner, tag)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err = rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+const setAccessSql = `
+INSERT INTO access_rules(kind, subject_id, allowed)
+VALUES ($1, $2, $3)
+ON CONFLICT (kind, subject_id) DO UPDATE SET allowed = excluded.allowed;
+`
+
+func (db *DB) SetAccess(kind string, id int64, allowed bool) error {
+
+	_, err := db.conn.Exec(setAccessSql, kind, id, allowed)
+	return err
+}
+
+const getAccessSql = `
+SELECT allowed FROM access_rules WHERE kind = $1 AND subject_id = $2;
+`
+
+func (db *DB) GetAccess(kind string, id int64) (bool, bool, error) {
+
+	var allowed bool
+	err := db.conn.QueryRow(getAccessSql, kind, id).Scan(&allowed)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return allowed, true, nil
+}
+
+const getSettingSql = `
+SELECT value FROM settings WHERE owner = $1 AND key = $2;
+`
+
+func (db *DB) GetSetting(owner, key string) (string, bool, error) {
+
+	var value string
+	err := db.conn.QueryRow(getSettingSql, owner, key).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return value, true, nil
+}
+
+const setSettingSql = `
+INSERT INTO settings(owner, key, value)
+VALUES ($1, $2, $3)
+ON CONFLICT (owner, key) DO UPDATE SET value = excluded.value;
+`
+
+func (db *DB) SetSetting(owner, key, value string) error {
+
+	_, err := db.conn.Exec(setSettingSql, owner, key, value)
+	return err
+}
+
+const deleteSettingSql = `
+DELETE FROM settings WHERE owner = $1 AND key = $2;
+`
+
+func (db *DB) DeleteSetting(owner, key string) error {
+
+	_, err := db.conn.Exec(deleteSettingSql, owner, key)
+	return err
 }
@@ -1,6 +1,7 @@
 package later_test
 
 import (
+	"errors"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 	"github.com/henges/later/later"
@@ -15,7 +16,7 @@ func TestLater(t *testing.T) {
 		t.Fatal(err)
 	}
 	var in = later.Reminder{Owner: "alex", FireTime: time.Now().Add(10 * time.Second), CallbackData: "hello"}
-	err = l.InsertReminder(in)
+	_, err = l.InsertReminder(in)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -38,14 +39,15 @@ func TestLater_Callbacks_Sync(t *testing.T) {
 		t.Fatal(err)
 	}
 	var in = later.Reminder{Owner: "alex", FireTime: time.Now().Add(-48 * time.Hour), CallbackData: "hello"}
-	err = l.InsertReminder(in)
+	_, err = l.InsertReminder(in)
 	if err != nil {
 		t.Fatal(err)
 	}
 	var results []later.Reminder
-	cb := func(r later.Reminder) {
+	cb := func(r later.SavedReminder) error {
 		// This should be called synchronously
-		results = append(results, r)
+		results = append(results, r.Reminder)
+		return nil
 	}
 	err = l.StartPoll(cb, 1*time.Second)
 	if err != nil {
@@ -77,14 +79,15 @@ func TestLater_Callbacks_Async(t *testing.T) {
 		t.Fatal(err)
 	}
 	var in = later.Reminder{Owner: "alex", FireTime: time.Now().Add(2 * time.Second), CallbackData: "hello"}
-	err = l.InsertReminder(in)
+	_, err = l.InsertReminder(in)
 	if err != nil {
 		t.Fatal(err)
 	}
 	var results []later.Reminder
-	cb := func(r later.Reminder) {
+	cb := func(r later.SavedReminder) error {
 		// This should be called synchronously
-		results = append(results, r)
+		results = append(results, r.Reminder)
+		return nil
 	}
 	err = l.StartPoll(cb, 200*time.Millisecond)
 	if err != nil {
@@ -113,3 +116,141 @@ func TestLater_Callbacks_Async(t *testing.T) {
 		t.Errorf("In and out differ:\n%s", cmp.Diff(in, out))
 	}
 }
+
+func TestLater_Hold(t *testing.T) {
+	l, err := later.NewLater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Truncate(time.Second)
+	until := now.Add(time.Hour)
+	for _, owner := range []string{"alex", "sam"} {
+		_, err = l.InsertReminder(later.Reminder{Owner: owner, FireTime: now.Add(-time.Minute), CallbackData: "hello"})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.SetHold(func(r later.Reminder, _ time.Time) (time.Time, bool) {
+		return until, r.Owner == "sam"
+	})
+	var fired []string
+	l.SetCallback(func(r later.SavedReminder) error {
+		fired = append(fired, r.Owner)
+		return nil
+	})
+	if err = l.FireDueReminders(now); err != nil {
+		t.Fatal(err)
+	}
+	if len(fired) != 1 || fired[0] != "alex" {
+		t.Errorf("expected only alex's reminder to fire, got %v", fired)
+	}
+	rs, err := l.GetRemindersByOwner("sam")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rs) != 1 || !rs[0].FireTime.Equal(until) {
+		t.Fatalf("expected sam's reminder to be held until %v, got %v", until, rs)
+	}
+
+	// Once the hold is over, it fires as usual.
+	fired = nil
+	if err = l.FireDueReminders(until); err != nil {
+		t.Fatal(err)
+	}
+	if len(fired) != 1 || fired[0] != "sam" {
+		t.Errorf("expected sam's reminder to fire, got %v", fired)
+	}
+}
+
+func TestLater_Access(t *testing.T) {
+
+	l, err := later.NewLater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, found, err := l.GetAccess("user", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Error("Expected no rule before one is set")
+	}
+	for _, want := range []bool{true, false} {
+		if err = l.SetAccess("user", 1, want); err != nil {
+			t.Fatal(err)
+		}
+		allowed, found, err := l.GetAccess("user", 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !found || allowed != want {
+			t.Errorf("Expected allowed=%t, got allowed=%t found=%t", want, allowed, found)
+		}
+	}
+}
+
+func TestLater_Limits(t *testing.T) {
+
+	l, err := later.NewLater(later.WithLimits(later.Limits{
+		MaxPendingPerOwner:   1,
+		MaxHorizon:           24 * time.Hour,
+		MaxDataLength:        5,
+		MaxDescriptionLength: 3,
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = l.InsertReminder(later.Reminder{Owner: "alex", FireTime: time.Now().Add(time.Hour), CallbackData: "hello"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var tooMany *later.TooManyPendingError
+	_, err = l.InsertReminder(later.Reminder{Owner: "alex", FireTime: time.Now().Add(time.Hour), CallbackData: "hi"})
+	if !errors.As(err, &tooMany) || !errors.Is(err, later.ErrLimitExceeded) {
+		t.Errorf("Expected TooManyPendingError, got %v", err)
+	}
+	var tooFar *later.TooFarAheadError
+	_, err = l.InsertReminder(later.Reminder{Owner: "sam", FireTime: time.Now().Add(48 * time.Hour), CallbackData: "hi"})
+	if !errors.As(err, &tooFar) {
+		t.Errorf("Expected TooFarAheadError, got %v", err)
+	}
+	var tooLong *later.DataTooLongError
+	_, err = l.InsertReminder(later.Reminder{Owner: "sam", FireTime: time.Now().Add(time.Hour), CallbackData: "hello!"})
+	if !errors.As(err, &tooLong) {
+		t.Errorf("Expected DataTooLongError, got %v", err)
+	}
+	// Characters are counted, not bytes.
+	if err := l.CheckDescription("чай"); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+	var descTooLong *later.DescriptionTooLongError
+	if err := l.CheckDescription("tea!"); !errors.As(err, &descTooLong) || !errors.Is(err, later.ErrLimitExceeded) {
+		t.Errorf("Expected DescriptionTooLongError, got %v", err)
+	}
+}
+
+func TestLater_InsertReminders(t *testing.T) {
+
+	l, err := later.NewLater(later.WithLimits(later.Limits{MaxPendingPerOwner: 2}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fireTime := time.Now().Add(time.Hour)
+	r := later.Reminder{Owner: "alex", FireTime: fireTime, CallbackData: "hello"}
+
+	var tooMany *later.TooManyPendingError
+	if _, err = l.InsertReminders([]later.Reminder{r, r, r}); !errors.As(err, &tooMany) {
+		t.Fatalf("Expected TooManyPendingError, got %v", err)
+	}
+	if n, _ := l.CountRemindersByOwner("alex"); n != 0 {
+		t.Errorf("Expected nothing to be inserted, got %d", n)
+	}
+	ids, err := l.InsertReminders([]later.Reminder{r, r})
+	if err != nil || len(ids) != 2 {
+		t.Fatalf("Expected 2 IDs, got %v, %v", ids, err)
+	}
+	if n, _ := l.CountRemindersByOwner("alex"); n != 2 {
+		t.Errorf("Expected 2 reminders, got %d", n)
+	}
+}
@@ -0,0 +1,107 @@
+package later
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits caps what owners can save. A zero value for any field means that
+// thing is unlimited.
+type Limits struct {
+	// MaxPendingPerOwner is the most reminders an owner can have waiting to
+	// fire.
+	MaxPendingPerOwner int
+	// MaxHorizon is how far in the future a reminder can be set.
+	MaxHorizon time.Duration
+	// MaxDataLength is the longest CallbackData, in bytes, a reminder can
+	// carry. It measures all of it, not whatever part of it a client treats
+	// as the description.
+	MaxDataLength int
+	// MaxDescriptionLength is the longest description, in characters, a
+	// client should accept. Later doesn't know which part of CallbackData is
+	// the description, so clients apply it with CheckDescription.
+	MaxDescriptionLength int
+}
+
+func WithLimits(limits Limits) Option {
+	return func(c *cfg) {
+		c.limits = limits
+	}
+}
+
+// ErrLimitExceeded is wrapped by every error returned when a reminder breaks
+// the configured Limits.
+var ErrLimitExceeded = errors.New("limit exceeded")
+
+type TooManyPendingError struct {
+	Max int
+}
+
+func (e *TooManyPendingError) Error() string {
+	return fmt.Sprintf("owner already has the maximum of %d pending reminders", e.Max)
+}
+
+func (e *TooManyPendingError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+type TooFarAheadError struct {
+	Max time.Duration
+}
+
+func (e *TooFarAheadError) Error() string {
+	return fmt.Sprintf("reminder is more than %s in the future", e.Max)
+}
+
+func (e *TooFarAheadError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+type DataTooLongError struct {
+	Max int
+}
+
+func (e *DataTooLongError) Error() string {
+	return fmt.Sprintf("reminder data is longer than %d bytes", e.Max)
+}
+
+func (e *DataTooLongError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+type DescriptionTooLongError struct {
+	Max int
+}
+
+func (e *DescriptionTooLongError) Error() string {
+	return fmt.Sprintf("description is longer than %d characters", e.Max)
+}
+
+func (e *DescriptionTooLongError) Unwrap() error {
+	return ErrLimitExceeded
+}
+
+// CheckDescription returns a DescriptionTooLongError if desc is longer than
+// the configured MaxDescriptionLength.
+func (l *Later) CheckDescription(desc string) error {
+
+	max := l.limits.MaxDescriptionLength
+	if max > 0 && utf8.RuneCountInString(desc) > max {
+		return &DescriptionTooLongError{max}
+	}
+	return nil
+}
+
+// checkReminder applies the limits that concern a single reminder.
+func (l Limits) checkReminder(r Reminder, now time.Time) error {
+
+	if l.MaxHorizon > 0 && r.FireTime.Sub(now) > l.MaxHorizon {
+		return &TooFarAheadError{l.MaxHorizon}
+	}
+	if l.MaxDataLength > 0 && len(r.CallbackData) > l.MaxDataLength {
+		return &DataTooLongError{l.MaxDataLength}
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package later
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultQueryLimit is the number of reminders returned by a query that
+	// doesn't give a Limit.
+	DefaultQueryLimit = 100
+	// MaxQueryLimit is the most reminders a query can return at once.
+	MaxQueryLimit = 1000
+)
+
+// Order is the order reminders are returned in, by fire time then ID.
+type Order int
+
+const (
+	Ascending Order = iota
+	Descending
+)
+
+// Query selects reminders for ListReminders. Zero fields don't filter.
+type Query struct {
+	Owner string
+	// From and To bound the fire time. From is inclusive, To exclusive.
+	From, To time.Time
+	// Tag matches reminders with the tag.
+	Tag string
+	// TextContains matches reminders whose callback data contains it,
+	// ignoring case.
+	TextContains string
+	// Search matches reminders whose text has words starting with each of its
+	// words, ignoring case and accents. A reminder's text is the name field of
+	// its callback data if that's a JSON object, else the whole callback data.
+	Search string
+	// Limit is the most reminders to return. It defaults to
+	// DefaultQueryLimit and can't be more than MaxQueryLimit.
+	Limit int
+	// Cursor continues a previous query from the reminder after the one it
+	// was made from, as returned in Page.NextCursor or by SavedReminder.Cursor.
+	Cursor string
+	Order  Order
+}
+
+// Page is a page of reminders returned by ListReminders.
+type Page struct {
+	Reminders []SavedReminder
+	// NextCursor continues the query from the end of this page, or is empty
+	// if this is the last page.
+	NextCursor string
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor returns a cursor for the position of r in a query's results.
+func (r SavedReminder) Cursor() string {
+	return strconv.FormatInt(r.FireTime.Unix(), 36) + "." + strconv.FormatInt(r.ID, 36)
+}
+
+func parseCursor(c string) (int64, int64, error) {
+
+	ts, id, ok := strings.Cut(c, ".")
+	if !ok {
+		return 0, 0, ErrInvalidCursor
+	}
+	fireTime, err := strconv.ParseInt(ts, 36, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	rowID, err := strconv.ParseInt(id, 36, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%w: %w", ErrInvalidCursor, err)
+	}
+	return fireTime, rowID, nil
+}
+
+// ListReminders returns the reminders matching q, a page at a time.
+func (l *Later) ListReminders(ctx context.Context, q Query) (Page, error) {
+	return l.db.ListReminders(ctx, q)
+}
+
+// CountMatching returns how many reminders match q's filters, ignoring its
+// Limit and Cursor.
+func (l *Later) CountMatching(ctx context.Context, q Query) (int, error) {
+	return l.db.CountMatching(ctx, q)
+}
+
+// filters returns the conditions selecting the reminders q filters for, and
+// their arguments.
+func (q Query) filters() ([]string, []any) {
+
+	// Deleted reminders are only kept to be restored.
+	where := []string{"deleted_at IS NULL"}
+	var args []any
+	if q.Owner != "" {
+		where = append(where, "owner = ?")
+		args = append(args, q.Owner)
+	}
+	if !q.From.IsZero() {
+		where = append(where, "fire_time >= ?")
+		args = append(args, q.From.Unix())
+	}
+	if !q.To.IsZero() {
+		where = append(where, "fire_time < ?")
+		args = append(args, q.To.Unix())
+	}
+	if q.Tag != "" {
+		where = append(where, "EXISTS (SELECT 1 FROM reminder_tags WHERE reminder_id = reminders.id AND tag = ?)")
+		args = append(args, q.Tag)
+	}
+	if q.TextContains != "" {
+		where = append(where, "instr(lower(callback_data), lower(?)) > 0")
+		args = append(args, q.TextContains)
+	}
+	if terms := searchTerms(q.Search); terms != "" {
+		where = append(where, "id IN (SELECT rowid FROM reminders_fts WHERE reminders_fts MATCH ?)")
+		args = append(args, terms)
+	}
+	return where, args
+}
+
+// searchTerms returns an FTS5 query matching text with words starting with
+// each of the words in s. The words are quoted, so nothing in s is taken as
+// query syntax.
+func searchTerms(s string) string {
+
+	words := strings.Fields(s)
+	for i, w := range words {
+		words[i] = `"` + strings.ReplaceAll(w, `"`, `""`) + `"*`
+	}
+	return strings.Join(words, " ")
+}
+
+const countMatchingSql = `
+SELECT count(*) FROM reminders
+`
+
+func (db *DB) CountMatching(ctx context.Context, q Query) (int, error) {
+
+	where, args := q.filters()
+	query := countMatchingSql + "WHERE " + strings.Join(where, " AND ") + ";"
+	var n int
+	err := db.conn.QueryRowContext(ctx, query, args...).Scan(&n)
+	return n, err
+}
+
+const listRemindersSql = `
+SELECT id, owner, fire_time, callback_data,
+    (SELECT json_group_array(tag) FROM reminder_tags WHERE reminder_id = reminders.id)
+FROM reminders
+`
+
+func (db *DB) ListReminders(ctx context.Context, q Query) (Page, error) {
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+	limit = min(limit, MaxQueryLimit)
+
+	where, args := q.filters()
+	cmp, dir := ">", "ASC"
+	if q.Order == Descending {
+		cmp, dir = "<", "DESC"
+	}
+	if q.Cursor != "" {
+		fireTime, id, err := parseCursor(q.Cursor)
+		if err != nil {
+			return Page{}, err
+		}
+		where = append(where, "(fire_time, id) "+cmp+" (?, ?)")
+		args = append(args, fireTime, id)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(listRemindersSql)
+	sb.WriteString("WHERE " + strings.Join(where, " AND ") + "\n")
+	// Fetch one extra row to find out whether there's another page.
+	sb.WriteString("ORDER BY fire_time " + dir + ", id " + dir + "\nLIMIT ?;")
+	args = append(args, limit+1)
+
+	rows, err := db.conn.QueryContext(ctx, sb.String(), args...)
+	if err != nil {
+		return Page{}, err
+	}
+	defer rows.Close()
+	var ret Page
+	for rows.Next() {
+		e := SavedReminder{}
+		var ts int64
+		var tags string
+		err = rows.Scan(&e.ID, &e.Owner, &ts, &e.CallbackData, &tags)
+		if err != nil {
+			return Page{}, err
+		}
+		e.FireTime = time.Unix(ts, 0)
+		if e.Tags, err = decodeTags(tags); err != nil {
+			return Page{}, err
+		}
+		ret.Reminders = append(ret.Reminders, e)
+	}
+	if err = rows.Err(); err != nil {
+		return Page{}, err
+	}
+	if len(ret.Reminders) > limit {
+		ret.Reminders = ret.Reminders[:limit]
+		ret.NextCursor = ret.Reminders[limit-1].Cursor()
+	}
+	return ret, nil
+}
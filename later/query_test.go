@@ -0,0 +1,221 @@
+package later_test
+
+import (
+	"context"
+	"errors"
+	"github.com/henges/later/later"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestLater_ListReminders(t *testing.T) {
+
+	l, err := later.NewLater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Now().Add(time.Hour).Truncate(time.Second)
+	// Some reminders share a fire time, so pages have to be ordered by ID
+	// too.
+	offsets := []int{3, 1, 2, 2, 0, 4, 2}
+	for i, o := range offsets {
+		data := "chores"
+		if i%2 == 0 {
+			data = "Shopping"
+		}
+		_, err = l.InsertReminder(later.Reminder{Owner: "alex", FireTime: base.Add(time.Duration(o) * time.Hour), CallbackData: data})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err = l.InsertReminder(later.Reminder{Owner: "sam", FireTime: base, CallbackData: "chores"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := func(q later.Query) []int64 {
+		var ret []int64
+		for {
+			page, err := l.ListReminders(context.Background(), q)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(page.Reminders) > q.Limit {
+				t.Fatalf("page of %d is over the limit of %d", len(page.Reminders), q.Limit)
+			}
+			for _, r := range page.Reminders {
+				ret = append(ret, r.ID)
+			}
+			if page.NextCursor == "" {
+				return ret
+			}
+			q.Cursor = page.NextCursor
+		}
+	}
+
+	tcs := []struct {
+		name     string
+		q        later.Query
+		expected []int64
+	}{
+		{"ascending", later.Query{Owner: "alex"}, []int64{5, 2, 3, 4, 7, 1, 6}},
+		{"descending", later.Query{Owner: "alex", Order: later.Descending}, []int64{6, 1, 7, 4, 3, 2, 5}},
+		{"range", later.Query{Owner: "alex", From: base.Add(time.Hour), To: base.Add(3 * time.Hour)}, []int64{2, 3, 4, 7}},
+		{"text", later.Query{Owner: "alex", TextContains: "shop"}, []int64{5, 3, 7, 1}},
+		{"all owners", later.Query{To: base.Add(time.Hour)}, []int64{5, 8}},
+	}
+	for _, tc := range tcs {
+		for _, limit := range []int{1, 3, 100} {
+			tc.q.Limit = limit
+			if res := ids(tc.q); !slices.Equal(res, tc.expected) {
+				t.Errorf("%s with limit %d: expected %v, got %v", tc.name, limit, tc.expected, res)
+			}
+		}
+	}
+
+	_, err = l.ListReminders(context.Background(), later.Query{Cursor: "nope"})
+	if !errors.Is(err, later.ErrInvalidCursor) {
+		t.Errorf("expected ErrInvalidCursor, got %v", err)
+	}
+}
+
+func TestLater_Tags(t *testing.T) {
+
+	l, err := later.NewLater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fireTime := time.Now().Add(time.Hour)
+	in := []later.Reminder{
+		{Owner: "alex", FireTime: fireTime, CallbackData: "a", Tags: []string{"work", "home"}},
+		{Owner: "alex", FireTime: fireTime, CallbackData: "b", Tags: []string{"work"}},
+		{Owner: "alex", FireTime: fireTime, CallbackData: "c"},
+		{Owner: "sam", FireTime: fireTime, CallbackData: "d", Tags: []string{"work"}},
+	}
+	for _, r := range in {
+		if _, err = l.InsertReminder(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r, _, err := l.GetReminderWithOwner("alex", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(r.Tags, []string{"home", "work"}) {
+		t.Errorf("expected sorted tags, got %v", r.Tags)
+	}
+	page, err := l.ListReminders(context.Background(), later.Query{Owner: "alex", Tag: "work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page.Reminders) != 2 || page.Reminders[0].ID != 1 || page.Reminders[1].ID != 2 {
+		t.Errorf("expected reminders 1 and 2 tagged work, got %v", page.Reminders)
+	}
+
+	// Updating a reminder replaces its tags.
+	r.Tags = []string{"home"}
+	if _, err = l.UpdateReminderWithOwner("alex", 1, r.Reminder); err != nil {
+		t.Fatal(err)
+	}
+	deleted, err := l.DeleteRemindersWithOwnerAndTag("alex", "work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(deleted, []int64{2}) {
+		t.Errorf("expected reminder 2 to be deleted, got %v", deleted)
+	}
+	rs, err := l.GetAllReminders()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, r := range rs {
+		ids = append(ids, r.ID)
+	}
+	if !slices.Equal(ids, []int64{1, 3, 4}) {
+		t.Errorf("expected reminders 1, 3 and 4 to be left, got %v", ids)
+	}
+
+	// A purged reminder's ID can be reused, but not its tags.
+	if _, err = l.DeleteReminderWithOwner("sam", 4); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = l.PurgeDeleted(time.Now().Add(time.Second)); err != nil {
+		t.Fatal(err)
+	}
+	id, err := l.InsertReminder(later.Reminder{Owner: "sam", FireTime: fireTime, CallbackData: "e"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, _, err = l.GetReminderWithOwner("sam", id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != 4 || r.Tags != nil {
+		t.Errorf("expected reminder 4 with no tags, got %v", r)
+	}
+}
+
+func TestLater_Search(t *testing.T) {
+
+	l, err := later.NewLater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fireTime := time.Now().Add(time.Hour)
+	for _, cbd := range []string{
+		`{"name":"Walk the dog","replyTo":1}`,
+		`{"name":"lavar a louça","replyTo":1}`,
+		`{"name":"buy dog food","replyTo":1}`,
+		`not json, walking`,
+	} {
+		if _, err = l.InsertReminder(later.Reminder{Owner: "alex", FireTime: fireTime, CallbackData: cbd}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	search := func(s string) []int64 {
+		page, err := l.ListReminders(context.Background(), later.Query{Owner: "alex", Search: s})
+		if err != nil {
+			t.Fatalf("searching for '%s': %v", s, err)
+		}
+		var ret []int64
+		for _, r := range page.Reminders {
+			ret = append(ret, r.ID)
+		}
+		return ret
+	}
+	tcs := []struct {
+		search   string
+		expected []int64
+	}{
+		{"dog", []int64{1, 3}},
+		{"DOG walk", []int64{1}},
+		{"walk", []int64{1, 4}},
+		{"louca", []int64{2}},
+		{"replyTo", nil},
+		{`dog" OR "food`, nil},
+		{"dog -", []int64{1, 3}},
+	}
+	for _, tc := range tcs {
+		if res := search(tc.search); !slices.Equal(res, tc.expected) {
+			t.Errorf("searching for '%s': expected %v, got %v", tc.search, tc.expected, res)
+		}
+	}
+
+	// Updated and deleted reminders are kept in sync.
+	_, err = l.UpdateReminderWithOwner("alex", 3, later.Reminder{Owner: "alex", FireTime: fireTime, CallbackData: `{"name":"buy cat food"}`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = l.DeleteReminderWithOwner("alex", 1); err != nil {
+		t.Fatal(err)
+	}
+	if res := search("dog"); res != nil {
+		t.Errorf("expected no dogs left, got %v", res)
+	}
+	if res := search("cat"); !slices.Equal(res, []int64{3}) {
+		t.Errorf("expected the updated reminder, got %v", res)
+	}
+}
@@ -2,9 +2,126 @@ CREATE TABLE IF NOT EXISTS reminders (
     id integer primary key,
     owner text not null,
     fire_time int not null,
-    callback_data text not null
+    callback_data text not null,
+    -- deleted_at is when the reminder was deleted, if it was. Deleted
+    -- reminders are kept for a while so they can be restored.
+    deleted_at int
 );
 
 CREATE INDEX IF NOT EXISTS idx_reminders_owner ON reminders(owner);
 
-CREATE INDEX IF NOT EXISTS idx_reminders_fire_time ON reminders(fire_time);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_reminders_deleted_at ON reminders(deleted_at) WHERE deleted_at IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_reminders_fire_time ON reminders(fire_time);
+
+CREATE TABLE IF NOT EXISTS access_rules (
+    kind text not null,
+    subject_id int not null,
+    allowed int not null,
+    primary key (kind, subject_id)
+);
+
+CREATE TABLE IF NOT EXISTS settings (
+    owner text not null,
+    key text not null,
+    value text not null,
+    primary key (owner, key)
+);
+CREATE TABLE IF NOT EXISTS reminder_tags (
+    reminder_id int not null,
+    tag text not null,
+    primary key (reminder_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reminder_tags_tag ON reminder_tags(tag);
+
+CREATE TRIGGER IF NOT EXISTS reminders_delete_tags AFTER DELETE ON reminders
+BEGIN
+    DELETE FROM reminder_tags WHERE reminder_id = old.id;
+END;
+
+-- reminders_fts indexes the text of each reminder for Query.Search: the name
+-- field of its callback data if that's JSON, else the whole callback data.
+CREATE VIRTUAL TABLE IF NOT EXISTS reminders_fts USING fts5(
+    body,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS reminders_fts_insert AFTER INSERT ON reminders
+BEGIN
+    INSERT INTO reminders_fts(rowid, body) VALUES (
+        new.id,
+        CASE WHEN json_valid(new.callback_data) THEN json_extract(new.callback_data, '$.name') ELSE new.callback_data END
+    );
+END;
+
+CREATE TRIGGER IF NOT EXISTS reminders_fts_update AFTER UPDATE OF callback_data ON reminders
+BEGIN
+    UPDATE reminders_fts SET body =
+        CASE WHEN json_valid(new.callback_data) THEN json_extract(new.callback_data, '$.name') ELSE new.callback_data END
+    WHERE rowid = new.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS reminders_fts_delete AFTER DELETE ON reminders
+BEGIN
+    DELETE FROM reminders_fts WHERE rowid = old.id;
+END;
+
+-- Deleted reminders leave the index, and come back if they're restored.
+CREATE TRIGGER IF NOT EXISTS reminders_fts_soft_delete AFTER UPDATE OF deleted_at ON reminders
+WHEN old.deleted_at IS NULL AND new.deleted_at IS NOT NULL
+BEGIN
+    DELETE FROM reminders_fts WHERE rowid = new.id;
+END;
+
+CREATE TRIGGER IF NOT EXISTS reminders_fts_restore AFTER UPDATE OF deleted_at ON reminders
+WHEN old.deleted_at IS NOT NULL AND new.deleted_at IS NULL
+BEGIN
+    INSERT INTO reminders_fts(rowid, body) VALUES (
+        new.id,
+        CASE WHEN json_valid(new.callback_data) THEN json_extract(new.callback_data, '$.name') ELSE new.callback_data END
+    );
+END;
+
+-- Index reminders saved before reminders_fts existed.
+INSERT INTO reminders_fts(rowid, body)
+SELECT id, CASE WHEN json_valid(callback_data) THEN json_extract(callback_data, '$.name') ELSE callback_data END
+FROM reminders
+WHERE deleted_at IS NULL AND id NOT IN (SELECT rowid FROM reminders_fts);
+
+-- history keeps fired reminders. reminder_id is the ID the reminder had
+-- while pending, which can since have been reused.
+CREATE TABLE IF NOT EXISTS history (
+    id integer primary key,
+    reminder_id int not null,
+    owner text not null,
+    fire_time int not null,
+    callback_data text not null,
+    delivered_at int not null,
+    status text not null,
+    error text not null default '',
+    acknowledged_at int
+);
+
+CREATE INDEX IF NOT EXISTS idx_history_owner ON history(owner, delivered_at);
+
+CREATE INDEX IF NOT EXISTS idx_history_delivered_at ON history(delivered_at);
+
+-- history_fts is to history what reminders_fts is to reminders.
+CREATE VIRTUAL TABLE IF NOT EXISTS history_fts USING fts5(
+    body,
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS history_fts_insert AFTER INSERT ON history
+BEGIN
+    INSERT INTO history_fts(rowid, body) VALUES (
+        new.id,
+        CASE WHEN json_valid(new.callback_data) THEN json_extract(new.callback_data, '$.name') ELSE new.callback_data END
+    );
+END;
+
+CREATE TRIGGER IF NOT EXISTS history_fts_delete AFTER DELETE ON history
+BEGIN
+    DELETE FROM history_fts WHERE rowid = old.id;
+END;
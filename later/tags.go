@@ -0,0 +1,36 @@
+package later
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// MaxTagBytes is the longest a tag can be. The bot puts tags in the data of
+// /list's buttons, which Telegram limits to 64 bytes, so they're kept short.
+const MaxTagBytes = 16
+
+var tagCharsPattern = regexp.MustCompile(`^[\p{L}\p{N}_]+$`)
+
+// NormalizeTags lowercases tags and drops any leading '#' and duplicates, so
+// they match the tags the bot takes from hashtags. It returns an error if a
+// tag isn't made of letters, digits and underscores, or is longer than
+// MaxTagBytes.
+func NormalizeTags(tags []string) ([]string, error) {
+
+	var ret []string
+	for _, t := range tags {
+		tag := strings.ToLower(strings.TrimPrefix(t, "#"))
+		if !tagCharsPattern.MatchString(tag) {
+			return nil, fmt.Errorf("tag '%s' must be letters, digits and underscores", t)
+		}
+		if len(tag) > MaxTagBytes {
+			return nil, fmt.Errorf("tag '%s' is longer than %d bytes", t, MaxTagBytes)
+		}
+		if !slices.Contains(ret, tag) {
+			ret = append(ret, tag)
+		}
+	}
+	return ret, nil
+}
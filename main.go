@@ -1,74 +1,86 @@
 package main
 
 import (
-	"context"
-	"encoding/json"
-	"github.com/henges/later/app"
-	"github.com/henges/later/bot"
+	"flag"
+	"fmt"
+	"github.com/henges/later/config"
 	"github.com/henges/later/later"
-	"github.com/olebedev/when"
-	"github.com/olebedev/when/rules/common"
-	"github.com/olebedev/when/rules/en"
-	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"os"
-	"os/signal"
-	"syscall"
+	"time"
 )
 
+type subcommand struct {
+	name  string
+	usage string
+	run   func(g *globals, args []string) error
+}
+
+var subcommands = []subcommand{
+	{"serve", "run the bot, API and poller (default)", runServe},
+	{"list", "list reminders, optionally for one owner", runList},
+	{"add", "add a reminder", runAdd},
+	{"delete", "delete a reminder by ID", runDelete},
+	{"migrate", "create or update the database schema", runMigrate},
+	{"export", "write all reminders as JSON to stdout", runExport},
+	{"import", "read reminders as JSON from stdin, all or none", runImport},
+	{"fire-due", "fire reminders that are due now", runFireDue},
+}
+
+type globals struct {
+	conf *config.Config
+}
+
 func main() {
 
-	zerolog.SetGlobalLevel(zerolog.TraceLevel)
-	file, err := os.ReadFile("./config.json")
-	if err != nil {
-		log.Fatal().Err(err).Send()
+	var configPath, dbName string
+	fs := flag.NewFlagSet("later", flag.ExitOnError)
+	fs.StringVar(&configPath, "config", "", "path to the config file (default $LATER_CONFIG or "+config.DefaultPath+")")
+	fs.StringVar(&dbName, "db", "", "database to operate on, overriding the config")
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintln(out, "Usage: later [flags] <command> [command flags]\n\nCommands:")
+		for _, c := range subcommands {
+			fmt.Fprintf(out, "  %-10s %s\n", c.name, c.usage)
+		}
+		fmt.Fprintln(out, "\nFlags:")
+		fs.PrintDefaults()
 	}
+	_ = fs.Parse(os.Args[1:])
 
-	conf := bot.Config{}
-	err = json.Unmarshal(file, &conf)
+	conf, err := config.Load(configPath)
 	if err != nil {
-		log.Fatal().Err(err).Send()
+		log.Fatal().Err(err).Msg("while loading config")
 	}
-	if conf.ListenPort == 0 {
-		conf.ListenPort = 23150
+	if dbName != "" {
+		conf.DBName = dbName
 	}
-
-	l, err := later.NewLater(later.WithDBName("file:later.db"))
-	if err != nil {
-		log.Fatal().Err(err).Send()
+	if err = conf.SetupLogging(); err != nil {
+		log.Fatal().Err(err).Msg("while setting up logging")
 	}
-	w := setupWhen()
-	cmds := bot.Commands{
-		app.NewSetReminderCommand(l, w),
-		app.NewListRemindersCommand(l, w),
-		app.NewDeleteReminderCommand(l, w),
+	g := &globals{conf}
+
+	name, args := "serve", fs.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
 	}
-	cmds = append(cmds, app.NewHelpCommand(cmds))
-	cmds = append(cmds, app.NewStartCommand())
-	webhookBot, err := bot.NewWebhookBot(&conf, cmds)
-	if err != nil {
-		log.Fatal().Err(err).Send()
+	for _, c := range subcommands {
+		if c.name == name {
+			if err := c.run(g, args); err != nil {
+				log.Fatal().Err(err).Send()
+			}
+			return
+		}
 	}
-	webhookBot.Start()
-	err = app.StartPolling(l, webhookBot.GetBot())
-	if err != nil {
-		log.Fatal().Err(err).Send()
-		return
-	}
-	defer l.StopPoll()
-
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	log.Info().Msg("App ready")
-
-	<-ctx.Done()
-	stop()
-	err = webhookBot.Stop()
-	log.Info().Err(err).Msg("App shutdown")
+	fs.Usage()
+	os.Exit(2)
 }
 
-func setupWhen() *when.Parser {
-	w := when.New(nil)
-	w.Add(en.All...)
-	w.Add(common.All...)
-	return w
+func (g *globals) openLater() (*later.Later, error) {
+	return later.NewLater(
+		later.WithDBName(g.conf.DBName),
+		later.WithLimits(g.conf.Limits.Later()),
+		later.WithHistoryRetention(time.Duration(g.conf.HistoryRetention)),
+		later.WithDeletedRetention(time.Duration(g.conf.DeletedRetention)),
+	)
 }
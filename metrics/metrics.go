@@ -0,0 +1,65 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+const namespace = "later"
+
+var (
+	RemindersCreated = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reminders_created_total",
+		Help:      "Number of reminders saved.",
+	})
+	RemindersFired = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reminders_fired_total",
+		Help:      "Number of reminders fired by the poller.",
+	})
+	RemindersFailed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reminders_failed_total",
+		Help:      "Number of fired reminders that couldn't be delivered.",
+	})
+	FireLatency = promauto.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "fire_latency_seconds",
+		Help:      "Delay between a reminder's fire time and when it was actually fired.",
+		Buckets:   []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60, 300, 3600},
+	})
+	TelegramErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "telegram_api_errors_total",
+		Help:      "Number of errors returned by the Telegram API, by method and error code.",
+	}, []string{"method", "code"})
+	HandlerDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "handler_duration_seconds",
+		Help:      "Time taken to handle a bot command.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"command"})
+)
+
+// RegisterPendingReminders exports the pending queue depth, calling count
+// whenever metrics are scraped.
+func RegisterPendingReminders(count func() (int, error)) {
+	promauto.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "pending_reminders",
+		Help:      "Number of reminders waiting to fire.",
+	}, func() float64 {
+		n, err := count()
+		if err != nil {
+			return -1
+		}
+		return float64(n)
+	})
+}
+
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,73 @@
+package rpc
+
+import (
+	"context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"strings"
+)
+
+type ownerKey struct{}
+
+// authenticate returns ctx with the owner named by the bearer token in its
+// "authorization" metadata.
+func (s *Server) authenticate(ctx context.Context) (context.Context, error) {
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	var header string
+	if vs := md.Get("authorization"); len(vs) > 0 {
+		header = vs[0]
+	}
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok {
+		return nil, status.Error(codes.Unauthenticated, "missing bearer token")
+	}
+	owner, ok := s.c.Tokens[token]
+	if !ok || token == "" {
+		return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+	}
+	return context.WithValue(ctx, ownerKey{}, owner), nil
+}
+
+// authedOwner returns the owner a request may act for: the one its token
+// authenticates as. An owner given in the request must match it.
+func authedOwner(ctx context.Context, requested string) (string, error) {
+
+	owner, _ := ctx.Value(ownerKey{}).(string)
+	if requested != "" && requested != owner {
+		return "", status.Error(codes.PermissionDenied, "token does not grant access to this owner")
+	}
+	return owner, nil
+}
+
+type authedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authedStream) Context() context.Context {
+	return s.ctx
+}
+
+// ServerOptions returns the options the grpc.Server the service is registered
+// on must be made with, which authenticate every call.
+func (s *Server) ServerOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			ctx, err := s.authenticate(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			ctx, err := s.authenticate(ss.Context())
+			if err != nil {
+				return err
+			}
+			return handler(srv, &authedStream{ss, ctx})
+		}),
+	}
+}
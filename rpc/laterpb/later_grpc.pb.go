@@ -0,0 +1,259 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: later.proto
+
+package laterpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Later_Schedule_FullMethodName = "/later.v1.Later/Schedule"
+	Later_Cancel_FullMethodName   = "/later.v1.Later/Cancel"
+	Later_List_FullMethodName     = "/later.v1.Later/List"
+	Later_Watch_FullMethodName    = "/later.v1.Later/Watch"
+)
+
+// LaterClient is the client API for Later service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Later schedules reminders that fire at a given time. Every call must send
+// "authorization: Bearer <token>" metadata, and acts for the owner the token
+// authenticates as. Owner fields can be left empty, and must name that owner
+// if they're set.
+type LaterClient interface {
+	// Schedule saves a reminder to be fired at its fire time.
+	Schedule(ctx context.Context, in *ScheduleRequest, opts ...grpc.CallOption) (*ScheduledReminder, error)
+	// Cancel deletes a pending reminder.
+	Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelResponse, error)
+	// List returns the pending reminders for an owner.
+	List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error)
+	// Watch streams reminders as they fire, with the IDs Schedule returned. A
+	// client that falls behind holds up firing until it catches up.
+	Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ScheduledReminder], error)
+}
+
+type laterClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewLaterClient(cc grpc.ClientConnInterface) LaterClient {
+	return &laterClient{cc}
+}
+
+func (c *laterClient) Schedule(ctx context.Context, in *ScheduleRequest, opts ...grpc.CallOption) (*ScheduledReminder, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ScheduledReminder)
+	err := c.cc.Invoke(ctx, Later_Schedule_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laterClient) Cancel(ctx context.Context, in *CancelRequest, opts ...grpc.CallOption) (*CancelResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CancelResponse)
+	err := c.cc.Invoke(ctx, Later_Cancel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laterClient) List(ctx context.Context, in *ListRequest, opts ...grpc.CallOption) (*ListResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListResponse)
+	err := c.cc.Invoke(ctx, Later_List_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *laterClient) Watch(ctx context.Context, in *WatchRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ScheduledReminder], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Later_ServiceDesc.Streams[0], Later_Watch_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchRequest, ScheduledReminder]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Later_WatchClient = grpc.ServerStreamingClient[ScheduledReminder]
+
+// LaterServer is the server API for Later service.
+// All implementations must embed UnimplementedLaterServer
+// for forward compatibility.
+//
+// Later schedules reminders that fire at a given time. Every call must send
+// "authorization: Bearer <token>" metadata, and acts for the owner the token
+// authenticates as. Owner fields can be left empty, and must name that owner
+// if they're set.
+type LaterServer interface {
+	// Schedule saves a reminder to be fired at its fire time.
+	Schedule(context.Context, *ScheduleRequest) (*ScheduledReminder, error)
+	// Cancel deletes a pending reminder.
+	Cancel(context.Context, *CancelRequest) (*CancelResponse, error)
+	// List returns the pending reminders for an owner.
+	List(context.Context, *ListRequest) (*ListResponse, error)
+	// Watch streams reminders as they fire, with the IDs Schedule returned. A
+	// client that falls behind holds up firing until it catches up.
+	Watch(*WatchRequest, grpc.ServerStreamingServer[ScheduledReminder]) error
+	mustEmbedUnimplementedLaterServer()
+}
+
+// UnimplementedLaterServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedLaterServer struct{}
+
+func (UnimplementedLaterServer) Schedule(context.Context, *ScheduleRequest) (*ScheduledReminder, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Schedule not implemented")
+}
+func (UnimplementedLaterServer) Cancel(context.Context, *CancelRequest) (*CancelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Cancel not implemented")
+}
+func (UnimplementedLaterServer) List(context.Context, *ListRequest) (*ListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method List not implemented")
+}
+func (UnimplementedLaterServer) Watch(*WatchRequest, grpc.ServerStreamingServer[ScheduledReminder]) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedLaterServer) mustEmbedUnimplementedLaterServer() {}
+func (UnimplementedLaterServer) testEmbeddedByValue()               {}
+
+// UnsafeLaterServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to LaterServer will
+// result in compilation errors.
+type UnsafeLaterServer interface {
+	mustEmbedUnimplementedLaterServer()
+}
+
+func RegisterLaterServer(s grpc.ServiceRegistrar, srv LaterServer) {
+	// If the following call pancis, it indicates UnimplementedLaterServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Later_ServiceDesc, srv)
+}
+
+func _Later_Schedule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ScheduleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaterServer).Schedule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Later_Schedule_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaterServer).Schedule(ctx, req.(*ScheduleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Later_Cancel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CancelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaterServer).Cancel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Later_Cancel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaterServer).Cancel(ctx, req.(*CancelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Later_List_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(LaterServer).List(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Later_List_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(LaterServer).List(ctx, req.(*ListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Later_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(LaterServer).Watch(m, &grpc.GenericServerStream[WatchRequest, ScheduledReminder]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Later_WatchServer = grpc.ServerStreamingServer[ScheduledReminder]
+
+// Later_ServiceDesc is the grpc.ServiceDesc for Later service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Later_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "later.v1.Later",
+	HandlerType: (*LaterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Schedule",
+			Handler:    _Later_Schedule_Handler,
+		},
+		{
+			MethodName: "Cancel",
+			Handler:    _Later_Cancel_Handler,
+		},
+		{
+			MethodName: "List",
+			Handler:    _Later_List_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Later_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "later.proto",
+}
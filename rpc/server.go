@@ -0,0 +1,204 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"github.com/henges/later/later"
+	"github.com/henges/later/rpc/laterpb"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"sync"
+)
+
+type Config struct {
+	// ListenPort is the port the gRPC service listens on. If zero, the
+	// service is disabled.
+	ListenPort int `json:"listenPort"`
+	// Tokens maps bearer tokens to the owner they authenticate as. Every call
+	// needs one, and can only act for that owner.
+	Tokens map[string]string `json:"tokens"`
+}
+
+// watchBuffer is the number of fired reminders buffered per Watch stream
+// before firing waits for the client to catch up.
+const watchBuffer = 64
+
+// errNotWatched is recorded as the reason a reminder failed when its owner
+// had no Watch stream open to deliver it to.
+var errNotWatched = errors.New("no watch stream is open for the owner")
+
+type Server struct {
+	laterpb.UnimplementedLaterServer
+	l *later.Later
+	c *Config
+
+	// done is closed by Stop, to end Watch streams.
+	done     chan struct{}
+	stopOnce sync.Once
+
+	mu       sync.Mutex
+	watchers map[string]int
+}
+
+func NewServer(l *later.Later, c *Config) *Server {
+	return &Server{l: l, c: c, done: make(chan struct{}), watchers: make(map[string]int)}
+}
+
+// Stop ends every Watch stream, which otherwise only end when the client
+// goes away, so the gRPC server can stop gracefully.
+func (s *Server) Stop() {
+	s.stopOnce.Do(func() { close(s.done) })
+}
+
+// Callback wraps next so that the reminders of owners with a token aren't
+// passed to it. Those reminders aren't for Telegram: Watch delivers them as
+// a subscriber, and they fail if their owner isn't watching.
+func (s *Server) Callback(next later.Callback) later.Callback {
+
+	owners := make(map[string]bool, len(s.c.Tokens))
+	for _, owner := range s.c.Tokens {
+		owners[owner] = true
+	}
+	return func(r later.SavedReminder) error {
+		if !owners[r.Owner] {
+			return next(r)
+		}
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.watchers[r.Owner] == 0 {
+			return errNotWatched
+		}
+		return nil
+	}
+}
+
+func (s *Server) Register(g *grpc.Server) {
+	laterpb.RegisterLaterServer(g, s)
+}
+
+func (s *Server) Schedule(ctx context.Context, req *laterpb.ScheduleRequest) (*laterpb.ScheduledReminder, error) {
+
+	pr := req.GetReminder()
+	owner, err := authedOwner(ctx, pr.GetOwner())
+	if err != nil {
+		return nil, err
+	}
+	if pr.GetFireTime() == nil {
+		return nil, status.Error(codes.InvalidArgument, "fire_time is required")
+	}
+	r := fromProto(pr)
+	r.Owner = owner
+	id, err := s.l.InsertReminder(r)
+	if errors.Is(err, later.ErrLimitExceeded) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return toProto(later.SavedReminder{ID: id, Reminder: r}), nil
+}
+
+func (s *Server) Cancel(ctx context.Context, req *laterpb.CancelRequest) (*laterpb.CancelResponse, error) {
+
+	owner, err := authedOwner(ctx, req.GetOwner())
+	if err != nil {
+		return nil, err
+	}
+	cancelled, err := s.l.DeleteReminderWithOwner(owner, req.GetId())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &laterpb.CancelResponse{Cancelled: cancelled}, nil
+}
+
+func (s *Server) List(ctx context.Context, req *laterpb.ListRequest) (*laterpb.ListResponse, error) {
+
+	owner, err := authedOwner(ctx, req.GetOwner())
+	if err != nil {
+		return nil, err
+	}
+	rmds, err := s.l.GetRemindersByOwner(owner)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	ret := &laterpb.ListResponse{Reminders: make([]*laterpb.ScheduledReminder, len(rmds))}
+	for i, r := range rmds {
+		ret.Reminders[i] = toProto(r)
+	}
+	return ret, nil
+}
+
+func (s *Server) Watch(req *laterpb.WatchRequest, stream grpc.ServerStreamingServer[laterpb.ScheduledReminder]) error {
+
+	owner, err := authedOwner(stream.Context(), req.GetOwner())
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	s.watchers[owner]++
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if s.watchers[owner]--; s.watchers[owner] == 0 {
+			delete(s.watchers, owner)
+		}
+	}()
+
+	ctx := stream.Context()
+	fired := make(chan later.SavedReminder, watchBuffer)
+	// Once the buffer is full, firing waits for the stream rather than
+	// dropping reminders.
+	unsubscribe := s.l.Subscribe(func(r later.SavedReminder) {
+		if r.Owner != owner {
+			return
+		}
+		select {
+		case fired <- r:
+		case <-ctx.Done():
+			log.Warn().Str("owner", r.Owner).Int64("id", r.ID).Msg("watch stream closed before reminder was sent")
+		case <-s.done:
+		}
+	})
+	defer unsubscribe()
+	// Send headers now so clients know the subscription is live.
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case r := <-fired:
+			if err := stream.Send(toProto(r)); err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			return nil
+		case <-s.done:
+			return status.Error(codes.Unavailable, "server is stopping")
+		}
+	}
+}
+
+func fromProto(r *laterpb.Reminder) later.Reminder {
+	return later.Reminder{
+		Owner:        r.GetOwner(),
+		FireTime:     r.GetFireTime().AsTime(),
+		CallbackData: r.GetCallbackData(),
+	}
+}
+
+func toProto(r later.SavedReminder) *laterpb.ScheduledReminder {
+	return &laterpb.ScheduledReminder{
+		Id: r.ID,
+		Reminder: &laterpb.Reminder{
+			Owner:        r.Owner,
+			FireTime:     timestamppb.New(r.FireTime),
+			CallbackData: r.CallbackData,
+		},
+	}
+}
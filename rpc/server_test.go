@@ -0,0 +1,183 @@
+package rpc_test
+
+import (
+	"context"
+	"github.com/henges/later/later"
+	"github.com/henges/later/rpc"
+	"github.com/henges/later/rpc/laterpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"net"
+	"testing"
+	"time"
+)
+
+func newClient(t *testing.T, l *later.Later) laterpb.LaterClient {
+
+	return newClientFor(t, rpc.NewServer(l, &rpc.Config{Tokens: map[string]string{"alex-token": "alex", "sam-token": "sam"}}))
+}
+
+func newClientFor(t *testing.T, s *rpc.Server) laterpb.LaterClient {
+
+	ln := bufconn.Listen(1024 * 1024)
+	g := grpc.NewServer(s.ServerOptions()...)
+	s.Register(g)
+	go g.Serve(ln)
+	t.Cleanup(g.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return ln.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return laterpb.NewLaterClient(conn)
+}
+
+func TestServer_Auth(t *testing.T) {
+
+	l, err := later.NewLater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = l.InsertReminder(later.Reminder{Owner: "alex", FireTime: time.Now().Add(time.Hour), CallbackData: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	client := newClient(t, l)
+
+	tcs := []struct {
+		name   string
+		header string
+		owner  string
+		code   codes.Code
+	}{
+		{"no token", "", "alex", codes.Unauthenticated},
+		{"wrong token", "Bearer nope", "alex", codes.Unauthenticated},
+		{"someone else's", "Bearer sam-token", "alex", codes.PermissionDenied},
+		{"own", "Bearer alex-token", "alex", codes.OK},
+		{"implied owner", "Bearer alex-token", "", codes.OK},
+	}
+	for _, tc := range tcs {
+		ctx := context.Background()
+		if tc.header != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", tc.header)
+		}
+		res, err := client.List(ctx, &laterpb.ListRequest{Owner: tc.owner})
+		if code := status.Code(err); code != tc.code {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.code, err)
+			continue
+		}
+		if tc.code == codes.OK && len(res.GetReminders()) != 1 {
+			t.Errorf("%s: expected alex's reminder, got %v", tc.name, res.GetReminders())
+		}
+	}
+	// An empty owner only lists the caller's own reminders.
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer sam-token")
+	res, err := client.List(ctx, &laterpb.ListRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.GetReminders()) != 0 {
+		t.Errorf("sam can see alex's reminders: %v", res.GetReminders())
+	}
+}
+
+func TestServer_Watch(t *testing.T) {
+
+	l, err := later.NewLater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newClient(t, l)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer alex-token")
+	scheduled, err := client.Schedule(ctx, &laterpb.ScheduleRequest{Reminder: &laterpb.Reminder{
+		FireTime:     timestamppb.New(time.Now().Add(-time.Minute)),
+		CallbackData: "hello",
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	list, err := client.List(ctx, &laterpb.ListRequest{Owner: "alex"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list.GetReminders()) != 1 {
+		t.Fatal("Wrong len for reminders", len(list.GetReminders()))
+	}
+
+	stream, err := client.Watch(ctx, &laterpb.WatchRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Wait for the stream to be established before firing.
+	if _, err = stream.Header(); err != nil {
+		t.Fatal(err)
+	}
+	if err = l.FireDueReminders(time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	out, err := stream.Recv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.GetId() != scheduled.GetId() || out.GetReminder().GetOwner() != "alex" || out.GetReminder().GetCallbackData() != "hello" {
+		t.Errorf("Unexpected reminder %v", out)
+	}
+}
+
+func TestServer_Stop(t *testing.T) {
+
+	l, err := later.NewLater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := rpc.NewServer(l, &rpc.Config{Tokens: map[string]string{"alex-token": "alex"}})
+	client := newClientFor(t, s)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer alex-token")
+	stream, err := client.Watch(ctx, &laterpb.WatchRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = stream.Header(); err != nil {
+		t.Fatal(err)
+	}
+	s.Stop()
+	if _, err = stream.Recv(); status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected the stream to end when the server stops, got %v", err)
+	}
+}
+
+func TestServer_Callback(t *testing.T) {
+
+	l, err := later.NewLater()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := rpc.NewServer(l, &rpc.Config{Tokens: map[string]string{"alex-token": "alex"}})
+	var passed []string
+	cb := s.Callback(func(r later.SavedReminder) error {
+		passed = append(passed, r.Owner)
+		return nil
+	})
+	if err = cb(later.SavedReminder{Reminder: later.Reminder{Owner: "sam"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err = cb(later.SavedReminder{Reminder: later.Reminder{Owner: "alex"}}); err == nil {
+		t.Error("Expected an error for an owner who isn't watching")
+	}
+	if len(passed) != 1 || passed[0] != "sam" {
+		t.Errorf("Expected only sam's reminder to be passed on, got %v", passed)
+	}
+}